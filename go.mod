@@ -1,10 +1,11 @@
 module github.com/goaferlx/go-core
 
-go 1.19
+go 1.21
 
 require (
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/golang-migrate/migrate/v4 v4.15.2
+	github.com/lib/pq v1.10.9
 	github.com/sirupsen/logrus v1.8.1
 	golang.org/x/time v0.0.0-20220609170525-579cf78fd858
 )
@@ -39,17 +39,33 @@ func (s *Server) Start(errorChan chan error) {
 // Log implements the log.Logger interface.  Logging will be passed to the servers logger if one is declared, otherwise handled
 // by the log package singleton.
 func (s *Server) Log(msg string, fields ...interface{}) error {
+	return s.logger().Log(msg, fields...)
+}
+
+// LogLevel implements the log.LevelLogger interface, passing the level through to the servers logger.
+func (s *Server) LogLevel(level log.Level, msg string, fields ...interface{}) error {
+	return log.LogLevel(s.logger(), level, msg, fields...)
+}
+
+// Enabled implements the log.LevelLogger interface.
+func (s *Server) Enabled(level log.Level) bool {
+	return log.Enabled(s.logger(), level)
+}
+
+// logger returns the servers logger if one is declared, otherwise the log package singleton.
+func (s *Server) logger() log.Logger {
 	if s.Logger == nil {
-		return log.DefaultLogger.Log(msg, fields...)
+		return log.DefaultLogger
 	}
-	return s.Logger.Log(msg, fields...)
+	return s.Logger
 }
 
 // NewServer wraps and returns a net/http Server with pre-configured Timeouts, making it safer to
 // use in production environments, as the user cannot forget to set them.
 // Values used are suggested values only, the user can and should adapt them according to the use-case.
+// Errors logged by the net/http Server are written to the servers logger at log.LevelError.
 func NewServer(addr string, h http.Handler) *Server {
-	return &Server{
+	s := &Server{
 		Server: &http.Server{
 			Addr:         addr,
 			Handler:      h,
@@ -58,7 +74,8 @@ func NewServer(addr string, h http.Handler) *Server {
 			IdleTimeout:  DefaultIdleTimeout,
 		},
 	}
-
+	s.ErrorLog = log.NewStdLogger(s, log.LevelError)
+	return s
 }
 
 // NewClient returns a net/http Client with a pre-configured Timeout, making it safer to
@@ -1,9 +1,16 @@
 package http
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/goaferlx/go-core/log"
+	"github.com/goaferlx/go-core/log/logtest"
 )
 
 func TestRecoverPanic(t *testing.T) {
@@ -28,11 +35,25 @@ func TestRecoverPanic(t *testing.T) {
 		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			panic("something happened")
 		})
+		logger := logtest.New()
 		srv := NewServer("", nil)
+		srv.Logger = logger
 		srv.RecoverPanic(next).ServeHTTP(w, r)
 		if got := w.Code; got != http.StatusInternalServerError {
 			t.Errorf("expected status code %d, got %d", http.StatusOK, got)
 		}
+
+		logger.AssertLogged(t, "panic recovered", "panic", errors.New("something happened"), "method", "GET", "url", "/irrelevant")
+		entries := logger.Entries(logtest.AtLevel(log.LevelError))
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 error entry, got %v", entries)
+		}
+		if caller, _ := entries[0].Field(log.CallerKey); !strings.HasPrefix(fmt.Sprint(caller), "http/http_test.go:") {
+			t.Errorf("expected the panic site as caller, got %q", caller)
+		}
+		if stack, _ := entries[0].Field(log.StackKey); !strings.Contains(fmt.Sprint(stack), "TestRecoverPanic") {
+			t.Errorf("expected a stack trace of the handler, got %q", stack)
+		}
 	})
 
 }
@@ -134,3 +155,60 @@ func TestRateLimit(t *testing.T) {
 
 	})
 }
+
+func TestLogContext(t *testing.T) {
+	t.Run("adds request fields to the context logger", func(t *testing.T) {
+		logger := logtest.New()
+		srv := NewServer("", nil)
+		srv.Logger = logger
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.FromContext(r.Context()).Log("handled")
+		})
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.Header.Set(RequestIDHeader, "abc123")
+		srv.LogContext(next).ServeHTTP(w, r)
+
+		logger.AssertLogged(t, "handled", "request_id", "abc123", "method", "GET", "path", "/users")
+		if got := w.Header().Get(RequestIDHeader); got != "abc123" {
+			t.Errorf("expected request id header %q, got %q", "abc123", got)
+		}
+	})
+
+	t.Run("adds the trace context", func(t *testing.T) {
+		logger := logtest.New()
+		srv := NewServer("", nil)
+		srv.Logger = logger
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.FromContext(r.Context()).Log("handled")
+		})
+		for _, traceparent := range []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			r.Header.Set("traceparent", traceparent)
+			srv.LogContext(next).ServeHTTP(httptest.NewRecorder(), r)
+		}
+
+		entries := logger.Entries(logtest.WithMessage("handled"))
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, got %v", entries)
+		}
+		logger.AssertLogged(t, "handled", log.TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736", log.SpanIDKey, "00f067aa0ba902b7")
+		if _, ok := entries[1].Field(log.TraceIDKey); ok {
+			t.Errorf("expected an invalid traceparent to be ignored, got %v", entries[1])
+		}
+	})
+
+	t.Run("generates a request id", func(t *testing.T) {
+		srv := NewServer("", nil)
+		srv.Logger = log.New(log.Output(io.Discard))
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+		srv.LogContext(http.NotFoundHandler()).ServeHTTP(w, r)
+
+		if got := w.Header().Get(RequestIDHeader); got == "" {
+			t.Errorf("expected a generated request id")
+		}
+	})
+}
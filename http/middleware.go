@@ -1,10 +1,13 @@
 package http
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"sync"
 
+	"github.com/goaferlx/go-core/log"
+	"github.com/goaferlx/go-core/rand"
 	"golang.org/x/time/rate"
 )
 
@@ -61,6 +64,34 @@ func LogRequest(next http.Handler) http.Handler {
 	})
 }
 
+// RequestIDHeader is the header a request ID is read from, and written to on the response.
+const RequestIDHeader = "X-Request-ID"
+
+// LogContext adds the servers logger to the request context, along with the request ID, method and path,
+// so that log.FromContext(r.Context()) can be used anywhere in the call stack.  The request ID is taken
+// from the RequestIDHeader, or generated if the client did not send one, and is echoed on the response.
+// If the request carries a valid W3C traceparent header, its trace and span IDs are added as well.
+// Further fields, such as a user ID, can be added by later middleware with log.ContextWithFields.
+func (s *Server) LogContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			// the request is still served without an ID if one cannot be generated.
+			id, _ = rand.RandomString(12)
+		}
+		if id != "" {
+			w.Header().Set(RequestIDHeader, id)
+		}
+
+		ctx := log.NewContext(r.Context(), s.logger())
+		ctx = log.ContextWithFields(ctx, "request_id", id, "method", r.Method, "path", r.URL.Path)
+		if tc, ok := log.ParseTraceparent(r.Header.Get(log.TraceparentHeader)); ok {
+			ctx = log.ContextWithFields(ctx, log.TraceIDKey, tc.TraceID, log.SpanIDKey, tc.SpanID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 type Allower interface {
 	Allow() bool
 }
@@ -111,11 +142,26 @@ func (mw *RateLimiterMw) RateLimit() func(next http.Handler) http.Handler {
 
 // RecoverPanic will attempt to recover from any panics, log the reason for the panic and return
 // an internal server error.  This middleware should be applied at the start of any middleware chains.
+// The panic is logged at log.LevelError with the panic value, where it happened, the stack trace of the
+// panicking goroutine and the request method and URL.  http.ErrAbortHandler is re-panicked, as net/http expects.
 func (s *Server) RecoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
-			if err := recover(); err != nil {
-				s.Log("panic recovered")
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				err, ok := v.(error)
+				if !ok {
+					err = fmt.Errorf("%v", v)
+				}
+				s.LogLevel(log.LevelError, "panic recovered",
+					"panic", err,
+					log.CallerKey, log.Caller(1),
+					log.StackKey, log.Stack(1),
+					"method", r.Method,
+					"url", r.URL.String(),
+				)
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned when writing to a writer that has been closed.
+var ErrClosed = errors.New("log: writer closed")
+
+// OverflowPolicy decides what an AsyncWriter does with a write when its queue is full.
+type OverflowPolicy int
+
+const (
+	// Block waits for space in the queue, slowing the caller down to the speed of the writer.
+	Block OverflowPolicy = iota
+	// DropNewest discards the write that did not fit.
+	DropNewest
+	// DropOldest discards the oldest queued write to make room.
+	DropOldest
+)
+
+// DefaultAsyncQueueSize is the queue size used by NewAsyncWriter when size is not positive.
+const DefaultAsyncQueueSize = 1024
+
+// AsyncWriter queues writes and passes them to an underlying io.Writer on a separate goroutine,
+// so that a slow destination does not stall the caller.  It is safe for concurrent use, and each
+// call to Write is passed on whole, so it can be used as the output of a logger.
+type AsyncWriter struct {
+	w       io.Writer
+	policy  OverflowPolicy
+	queue   chan *[]byte
+	dropped atomic.Uint64
+	done    chan struct{}
+
+	closeMu sync.RWMutex // held for writing whilst closing the queue.
+	closed  bool
+
+	mu      sync.Mutex // guards pending and err.
+	idle    *sync.Cond // signalled when pending reaches zero.
+	pending int        // writes queued but not yet written or dropped.
+	err     error      // first error from the underlying writer since the last Flush.
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to w through a queue of size writes, applying policy when it is full.
+func NewAsyncWriter(w io.Writer, size int, policy OverflowPolicy) *AsyncWriter {
+	if size <= 0 {
+		size = DefaultAsyncQueueSize
+	}
+	a := &AsyncWriter{
+		w:      w,
+		policy: policy,
+		queue:  make(chan *[]byte, size),
+		done:   make(chan struct{}),
+	}
+	a.idle = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// Write queues a copy of p.  It only returns an error once the writer has been closed, errors from the
+// underlying writer are returned by Flush and Close.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	if a.closed {
+		return 0, ErrClosed
+	}
+
+	b := getBuffer()
+	*b = append(*b, p...)
+	a.mu.Lock()
+	a.pending++
+	a.mu.Unlock()
+
+	switch a.policy {
+	case DropNewest:
+		select {
+		case a.queue <- b:
+		default:
+			a.drop(b)
+		}
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- b:
+				return len(p), nil
+			default:
+			}
+			select {
+			case old := <-a.queue:
+				a.drop(old)
+			default:
+			}
+		}
+	default:
+		a.queue <- b
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of writes discarded because the queue was full.
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Flush waits until every queued write has been passed to the underlying writer, and returns the first
+// error it reported since the last Flush.
+func (a *AsyncWriter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.pending > 0 {
+		a.idle.Wait()
+	}
+	err := a.err
+	a.err = nil
+	return err
+}
+
+// Close stops accepting writes and drains the queue.  The underlying writer is not closed.
+func (a *AsyncWriter) Close() error {
+	a.closeMu.Lock()
+	if a.closed {
+		a.closeMu.Unlock()
+		return ErrClosed
+	}
+	a.closed = true
+	close(a.queue)
+	a.closeMu.Unlock()
+
+	<-a.done
+	return a.Flush()
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	for b := range a.queue {
+		_, err := a.w.Write(*b)
+		putBuffer(b)
+		a.mu.Lock()
+		if err != nil && a.err == nil {
+			a.err = err
+		}
+		a.done1()
+		a.mu.Unlock()
+	}
+}
+
+func (a *AsyncWriter) drop(b *[]byte) {
+	putBuffer(b)
+	a.dropped.Add(1)
+	a.mu.Lock()
+	a.done1()
+	a.mu.Unlock()
+}
+
+// done1 marks a queued write as finished, it must be called with a.mu held.
+func (a *AsyncWriter) done1() {
+	a.pending--
+	if a.pending == 0 {
+		a.idle.Broadcast()
+	}
+}
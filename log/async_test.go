@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks every write until the gate is opened.
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	<-g.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.Write(p)
+}
+
+func (g *gatedWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	tests := []struct {
+		policy  OverflowPolicy
+		want    string
+		dropped uint64
+	}{
+		// the first write is taken by the writer goroutine, leaving a queue of 2 for the remaining 4.
+		{policy: DropNewest, want: "abc", dropped: 2},
+		{policy: DropOldest, want: "ade", dropped: 2},
+	}
+	for _, tt := range tests {
+		g := &gatedWriter{gate: make(chan struct{})}
+		a := NewAsyncWriter(g, 2, tt.policy)
+		a.Write([]byte("a"))
+		// wait for the writer goroutine to pick up the first write.
+		for len(a.queue) != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for _, s := range []string{"b", "c", "d", "e"} {
+			a.Write([]byte(s))
+		}
+		close(g.gate)
+		if err := a.Close(); err != nil {
+			t.Fatalf("closing: %v", err)
+		}
+		if got := g.String(); got != tt.want {
+			t.Errorf("policy %d: expected %q written, got %q", tt.policy, tt.want, got)
+		}
+		if got := a.Dropped(); got != tt.dropped {
+			t.Errorf("policy %d: expected %d dropped, got %d", tt.policy, tt.dropped, got)
+		}
+		if _, err := a.Write([]byte("late")); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed after Close, got %v", err)
+		}
+	}
+
+	t.Run("block flushes everything", func(t *testing.T) {
+		var buf bytes.Buffer
+		a := NewAsyncWriter(&buf, 1, Block)
+		defer a.Close()
+		logger := New(Output(a), Format(LogfmtEncoder{}))
+		for i := 0; i < 100; i++ {
+			logger.Log("entry", "i", i)
+		}
+		if err := a.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(buf.String(), "\n"); got != 100 {
+			t.Errorf("expected 100 lines, got %d", got)
+		}
+	})
+}
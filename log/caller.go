@@ -0,0 +1,228 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Keys of the fields added by the AddCaller and AddStacktrace options.
+const (
+	CallerKey = "caller"
+	StackKey  = "stack"
+)
+
+// maxStackDepth limits the number of frames captured for a stack trace.
+const maxStackDepth = 64
+
+// packageDir is the directory of this package, used to skip its frames when finding the caller.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// AddCaller adds the file and line of the code that logged each entry under CallerKey, as
+// "dir/file.go:42".  Frames within this package are skipped, so the caller of wrappers such as
+// WithFields or Debug is reported rather than the wrapper itself.
+func AddCaller() Option {
+	return func(l *logger) {
+		l.caller = true
+	}
+}
+
+// AddStacktrace adds a stack trace of the code that logged each entry at or above level under
+// StackKey, unless the entry already has a field with that key.
+func AddStacktrace(level Level) Option {
+	return func(l *logger) {
+		l.stacktrace = true
+		l.stackLevel = level
+	}
+}
+
+// ErrorChains writes error fields as an object holding the message and type of the error and of
+// each error in its chain, following errors.Unwrap and errors.Join, rather than the message alone:
+//
+//	{"msg":"saving user: timeout","type":"*fmt.wrapError","causes":[{"msg":"timeout","type":"*net.OpError"}]}
+func ErrorChains() Option {
+	return func(l *logger) {
+		l.errorChains = true
+	}
+}
+
+// ErrorChain is how an error is written by a logger with the ErrorChains option.
+type ErrorChain struct {
+	Msg    string       `json:"msg"`
+	Type   string       `json:"type"`
+	Causes []ErrorChain `json:"causes,omitempty"`
+}
+
+// NewErrorChain returns the chain of err, following Unwrap() error and Unwrap() []error.
+func NewErrorChain(err error) ErrorChain {
+	return newErrorChain(err, 0)
+}
+
+func newErrorChain(err error, depth int) ErrorChain {
+	c := ErrorChain{Msg: err.Error(), Type: fmt.Sprintf("%T", err)}
+	if depth >= maxLogValueDepth {
+		return c
+	}
+	var causes []error
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := u.Unwrap(); cause != nil {
+			causes = []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		causes = u.Unwrap()
+	}
+	for _, cause := range causes {
+		if cause != nil {
+			c.Causes = append(c.Causes, newErrorChain(cause, depth+1))
+		}
+	}
+	return c
+}
+
+// annotate adds the caller and stack trace fields to e and expands error chains, as configured.
+func (l *logger) annotate(e *Entry) {
+	if l.caller && !hasField(e.Fields, CallerKey) {
+		if frame, ok := callerFrame(); ok {
+			e.Fields = append(e.Fields, Field{Key: CallerKey, Value: shortCaller(frame)})
+		}
+	}
+	if l.stacktrace && e.Level >= l.stackLevel && !hasField(e.Fields, StackKey) {
+		e.Fields = append(e.Fields, Field{Key: StackKey, Value: stacktrace()})
+	}
+	if l.errorChains {
+		for i, f := range e.Fields {
+			if err := errorValue(f.Value); err != nil {
+				e.Fields[i].Value = NewErrorChain(err)
+			}
+		}
+	}
+}
+
+// errorValue returns v resolved if it is an error, otherwise nil.
+func errorValue(v any) error {
+	if err, ok := resolve(v).(error); ok {
+		return err
+	}
+	return nil
+}
+
+func hasField(fields []Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// internalFrame reports whether frame belongs to this package, excluding its tests.
+func internalFrame(frame runtime.Frame) bool {
+	return filepath.Dir(frame.File) == packageDir && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+// callerFrame returns the first frame outside this package.
+func callerFrame() (runtime.Frame, bool) {
+	var pcs [maxStackDepth]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !internalFrame(frame) {
+			return frame, frame.PC != 0
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// shortCaller formats frame as the file, with its directory, and line.
+func shortCaller(frame runtime.Frame) string {
+	file := frame.File
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(frame.Line)
+}
+
+// stacktrace formats the stack from the first frame outside this package, in the style of
+// runtime/debug.Stack: the function on one line followed by its file and line indented below.
+func stacktrace() string {
+	var pcs [maxStackDepth]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	var b strings.Builder
+	skipping := true
+	for {
+		frame, more := frames.Next()
+		if skipping && internalFrame(frame) {
+			if !more {
+				break
+			}
+			continue
+		}
+		skipping = false
+		writeFrame(&b, frame)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+func writeFrame(b *strings.Builder, frame runtime.Frame) {
+	b.WriteString(frame.Function)
+	b.WriteString("\n\t")
+	b.WriteString(frame.File)
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(frame.Line))
+	b.WriteByte('\n')
+}
+
+// Caller returns the file and line of the function skip frames above the caller of Caller, formatted
+// as for CallerKey, or an empty string if there is no such frame.  Frames within the runtime are not
+// counted, so in a function deferred to recover from a panic Caller(1) is where the panic was raised.
+func Caller(skip int) string {
+	frames := userFrames(skip+1, 1)
+	if len(frames) == 0 {
+		return ""
+	}
+	return shortCaller(frames[0])
+}
+
+// Stack returns the stack from the function skip frames above the caller of Stack, formatted as for
+// StackKey.  As with Caller, frames within the runtime are neither counted nor included.
+func Stack(skip int) string {
+	var b strings.Builder
+	for _, frame := range userFrames(skip+1, maxStackDepth) {
+		writeFrame(&b, frame)
+	}
+	return b.String()
+}
+
+// userFrames returns up to max frames outside the runtime, starting skip frames above the caller of userFrames.
+func userFrames(skip, max int) []runtime.Frame {
+	var pcs [maxStackDepth]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	var out []runtime.Frame
+	for len(out) < max {
+		frame, more := frames.Next()
+		if frame.PC != 0 && !strings.HasPrefix(frame.Function, "runtime.") {
+			if skip > 0 {
+				skip--
+			} else {
+				out = append(out, frame)
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCallerAndStack(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(Output(&buf), AddCaller(), AddStacktrace(LevelError))
+	Info(WithFields(logger, "k", "v"), "wrapped")
+	Error(logger, "failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got %q", buf.String())
+	}
+	var info, failed map[string]any
+	json.Unmarshal([]byte(lines[0]), &info)
+	json.Unmarshal([]byte(lines[1]), &failed)
+	if caller, _ := info[CallerKey].(string); !strings.HasPrefix(caller, "log/caller_test.go:") {
+		t.Errorf("expected the test as caller, got %q", caller)
+	}
+	if _, ok := info[StackKey]; ok {
+		t.Errorf("expected no stack trace below the stack level, got %v", info[StackKey])
+	}
+	stack, _ := failed[StackKey].(string)
+	if !strings.HasPrefix(stack, "github.com/goaferlx/go-core/log.TestCallerAndStack\n\t") {
+		t.Errorf("expected the stack trace to start at the test, got %q", stack)
+	}
+
+	t.Run("panic site", func(t *testing.T) {
+		var line int
+		var caller, stack string
+		func() {
+			defer func() {
+				recover()
+				caller, stack = Caller(1), Stack(1)
+			}()
+			_, _, line, _ = runtime.Caller(0)
+			panic("boom")
+		}()
+		if want := "log/caller_test.go:" + strconv.Itoa(line+1); caller != want {
+			t.Errorf("expected caller %q, got %q", want, caller)
+		}
+		if !strings.HasPrefix(stack, "github.com/goaferlx/go-core/log.TestCallerAndStack.func1.1\n\t") || strings.Contains(stack, "runtime.") {
+			t.Errorf("expected the stack trace to start at the panic without runtime frames, got %q", stack)
+		}
+	})
+}
+
+type codeError struct{ code int }
+
+func (e codeError) Error() string { return "code " + strconv.Itoa(e.code) }
+
+func TestErrorChains(t *testing.T) {
+	var buf bytes.Buffer
+	err := fmt.Errorf("saving user: %w", errors.Join(codeError{1}, errors.New("timeout")))
+	New(Output(&buf), ErrorChains()).Log("failed", "error", err, "plain", "not an error")
+
+	var entry struct {
+		Error ErrorChain
+		Plain string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	want := ErrorChain{Msg: err.Error(), Type: "*fmt.wrapError", Causes: []ErrorChain{{
+		Msg:  "code 1\ntimeout",
+		Type: "*errors.joinError",
+		Causes: []ErrorChain{
+			{Msg: "code 1", Type: "log.codeError"},
+			{Msg: "timeout", Type: "*errors.errorString"},
+		},
+	}}}
+	if !reflect.DeepEqual(entry.Error, want) {
+		t.Errorf("expected chain %+v, got %+v", want, entry.Error)
+	}
+	if entry.Plain != "not an error" {
+		t.Errorf("expected other fields to be unchanged, got %q", entry.Plain)
+	}
+}
@@ -0,0 +1,33 @@
+package log
+
+import "context"
+
+type loggerKey struct{}
+
+type fieldsKey struct{}
+
+// NewContext returns a copy of ctx carrying l, to be retrieved with FromContext.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// ContextWithFields returns a copy of ctx carrying fields, in addition to any fields already in ctx.
+// The fields are added to every entry logged through the Logger returned by FromContext.
+func ContextWithFields(ctx context.Context, fields ...interface{}) context.Context {
+	existing, _ := ctx.Value(fieldsKey{}).([]interface{})
+	fields = append(existing[:len(existing):len(existing)], normalizeFields(fields)...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// FromContext returns the Logger carried by ctx, or DefaultLogger if there is none, wrapped with any
+// fields added by ContextWithFields.
+func FromContext(ctx context.Context) Logger {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		l = DefaultLogger
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).([]interface{}); ok && len(fields) > 0 {
+		return WithFields(l, fields...)
+	}
+	return l
+}
@@ -0,0 +1,247 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder formats an Entry.  Encode appends the complete record, including any terminating
+// newline, to buf and returns the extended buffer.
+type Encoder interface {
+	Encode(buf []byte, e *Entry) []byte
+}
+
+// EncoderConfig holds the settings shared by the built in encoders.  Empty values are replaced
+// with the defaults of each encoder.
+type EncoderConfig struct {
+	TimeKey    string // key of the entry timestamp.
+	MessageKey string // key of the entry message.
+	LevelKey   string // key of the entry level.
+	TimeFormat string // layout of the entry timestamp, as used by time.Format.
+}
+
+// merge returns c with its empty values taken from def.
+func (c EncoderConfig) merge(def EncoderConfig) EncoderConfig {
+	if c.TimeKey == "" {
+		c.TimeKey = def.TimeKey
+	}
+	if c.MessageKey == "" {
+		c.MessageKey = def.MessageKey
+	}
+	if c.LevelKey == "" {
+		c.LevelKey = def.LevelKey
+	}
+	if c.TimeFormat == "" {
+		c.TimeFormat = def.TimeFormat
+	}
+	return c
+}
+
+// apply sets the empty settings of the built in encoders from c.  Other encoders are returned unchanged.
+func (c EncoderConfig) apply(enc Encoder) Encoder {
+	switch enc := enc.(type) {
+	case JSONEncoder:
+		enc.EncoderConfig = enc.EncoderConfig.merge(c)
+		return enc
+	case LogfmtEncoder:
+		enc.EncoderConfig = enc.EncoderConfig.merge(c)
+		return enc
+	case ConsoleEncoder:
+		if enc.TimeFormat == "" {
+			enc.TimeFormat = c.TimeFormat
+		}
+		return enc
+	}
+	return enc
+}
+
+// JSONEncoder writes each entry as a single line JSON object.  The "timestamp" and "msg" keys are
+// written first, followed by "level" and then the fields in the order they were passed to Log.
+// Timestamps default to RFC3339.
+type JSONEncoder struct {
+	EncoderConfig
+}
+
+var defaultJSONConfig = EncoderConfig{
+	TimeKey:    "timestamp",
+	MessageKey: "msg",
+	LevelKey:   "level",
+	TimeFormat: time.RFC3339,
+}
+
+// Encode implements Encoder.
+func (enc JSONEncoder) Encode(buf []byte, e *Entry) []byte {
+	cfg := enc.merge(defaultJSONConfig)
+	buf = append(buf, '{')
+	buf = appendJSONString(buf, cfg.TimeKey)
+	buf = append(buf, ':', '"')
+	buf = e.Time.AppendFormat(buf, cfg.TimeFormat)
+	buf = append(buf, '"', ',')
+	buf = appendJSONString(buf, cfg.MessageKey)
+	buf = append(buf, ':')
+	buf = appendJSONString(buf, e.Message)
+	buf = append(buf, ',')
+	buf = appendJSONString(buf, cfg.LevelKey)
+	buf = append(buf, ':', '"')
+	buf = append(buf, e.Level.String()...)
+	buf = append(buf, '"')
+	for _, f := range e.Fields {
+		buf = append(buf, ',')
+		buf = appendJSONKey(buf, f.Key, cfg)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	return append(buf, '}', '\n')
+}
+
+// reservedKeyPrefix is prepended to field keys that collide with the timestamp, message or level keys.
+const reservedKeyPrefix = "fields."
+
+// reserved reports whether key is used by the encoder for the timestamp, message or level.
+func (c EncoderConfig) reserved(key string) bool {
+	return key == c.TimeKey || key == c.MessageKey || key == c.LevelKey
+}
+
+// appendJSONKey appends a quoted field key, prefixed if it collides with a reserved key.
+func appendJSONKey(buf []byte, key string, cfg EncoderConfig) []byte {
+	if !cfg.reserved(key) {
+		return appendJSONString(buf, key)
+	}
+	buf = append(buf, '"')
+	buf = append(buf, reservedKeyPrefix...)
+	n := len(buf)
+	buf = appendJSONString(buf, key)
+	// drop the opening quote written by appendJSONString.
+	copy(buf[n:], buf[n+1:])
+	return buf[:len(buf)-1]
+}
+
+// appendJSONValue appends v to buf with its native JSON type.  Errors are written as their message
+// and times in RFC3339Nano format.  Types implementing LogValuer or json.Marshaler are written through
+// those methods, other types not handled directly go through encoding/json.  If v cannot be marshalled
+// its fmt.Sprint form is written instead.
+func appendJSONValue(buf []byte, v any) []byte {
+	switch v := resolve(v).(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		return appendJSONFloat(buf, v, 64)
+	case time.Duration:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case time.Time:
+		buf = append(buf, '"')
+		buf = v.AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	case json.Marshaler:
+		return appendMarshalled(buf, v)
+	case error:
+		return appendJSONString(buf, v.Error())
+	default:
+		return appendMarshalled(buf, v)
+	}
+}
+
+// appendMarshalled appends v using encoding/json, falling back to the string form of v.
+func appendMarshalled(buf []byte, v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+	return append(buf, b...)
+}
+
+// appendJSONFloat formats f the same way as encoding/json.  NaN and infinities are not valid JSON
+// numbers so are written as strings.
+func appendJSONFloat(buf []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		buf = append(buf, '"')
+		buf = strconv.AppendFloat(buf, f, 'g', -1, bits)
+		return append(buf, '"')
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21)) {
+		format = 'e'
+	}
+	return strconv.AppendFloat(buf, f, format, -1, bits)
+}
+
+const hex = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string.  Invalid UTF-8 is replaced with U+FFFD.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON but break JavaScript parsers.
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// Field is a key/value pair attached to an Entry.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Entry is a single log entry, as passed to an Encoder.  Entries are reused once they have been
+// encoded, so an Encoder must not retain an Entry or its Fields.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  []Field
+}
+
+var entryPool = sync.Pool{
+	New: func() any {
+		return &Entry{Fields: make([]Field, 0, 16)}
+	},
+}
+
+func getEntry() *Entry {
+	return entryPool.Get().(*Entry)
+}
+
+func putEntry(e *Entry) {
+	// clear the fields so the pool does not keep the values alive.
+	for i := range e.Fields {
+		e.Fields[i] = Field{}
+	}
+	e.Fields = e.Fields[:0]
+	entryPool.Put(e)
+}
+
+// BadKey is the key a field is logged under when it is not preceded by a string key: either a
+// dangling value left by an odd number of arguments, or a key that is not a string.
+const BadKey = "!BADKEY"
+
+// appendFields appends the key/value pairs in fields to dst.  A string followed by a value forms a pair.
+// Any other argument, including a string with no value after it, is kept as a value under BadKey and
+// the next argument is treated as a key, so a single bad key does not misalign the remaining pairs.
+func appendFields(dst []Field, fields []any) []Field {
+	for i := 0; i < len(fields); {
+		key, ok := fields[i].(string)
+		if !ok || i+1 == len(fields) {
+			dst = append(dst, Field{Key: BadKey, Value: fields[i]})
+			i++
+			continue
+		}
+		dst = append(dst, Field{Key: key, Value: fields[i+1]})
+		i += 2
+	}
+	return dst
+}
+
+// normalizeFields returns fields as well formed key/value pairs, following the rules of appendFields.
+// The original slice is returned if it is already well formed.
+func normalizeFields(fields []any) []any {
+	if wellFormed(fields) {
+		return fields
+	}
+	var out []any
+	for _, f := range appendFields(nil, fields) {
+		out = append(out, f.Key, f.Value)
+	}
+	return out
+}
+
+func wellFormed(fields []any) bool {
+	if len(fields)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(fields); i += 2 {
+		if _, ok := fields[i].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// dedupe removes every field whose key is repeated later in fields, so the last value of a key wins
+// and is written at the position of its last occurrence.  Fields under BadKey are all kept.
+// The order of the remaining fields is unchanged.
+func dedupe(fields []Field) []Field {
+	n := 0
+outer:
+	for i, f := range fields {
+		if f.Key != BadKey {
+			for _, later := range fields[i+1:] {
+				if later.Key == f.Key {
+					continue outer
+				}
+			}
+		}
+		fields[n] = f
+		n++
+	}
+	for i := n; i < len(fields); i++ {
+		fields[i] = Field{}
+	}
+	return fields[:n]
+}
+
+// buffers holds byte slices that entries are encoded into.
+var buffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// maxBufferSize stops unusually large entries from pinning large buffers in the pool.
+const maxBufferSize = 64 << 10
+
+func getBuffer() *[]byte {
+	return buffers.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxBufferSize {
+		return
+	}
+	*b = (*b)[:0]
+	buffers.Put(b)
+}
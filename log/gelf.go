@@ -0,0 +1,108 @@
+package log
+
+import (
+	"crypto/rand"
+	"errors"
+	"net"
+	"strconv"
+)
+
+// GELF UDP chunking limits, from the GELF 1.1 specification.
+const (
+	gelfChunkSize   = 8192
+	gelfChunkHeader = 12
+	gelfMaxChunks   = 128
+)
+
+// errGELFTooLarge is returned for a record that does not fit in the maximum number of UDP chunks.
+var errGELFTooLarge = permanentError{errors.New("log: GELF message exceeds 128 chunks")}
+
+// GELFEncoder writes each entry as a GELF 1.1 JSON object.  Fields are written as additional fields
+// prefixed with an underscore, and the level as a syslog severity.  The object has no terminator,
+// framing is left to the transport, so it should be used with a writer from NewGELFWriter.
+type GELFEncoder struct {
+	Host string // defaults to os.Hostname.
+}
+
+// Encode implements Encoder.
+func (enc GELFEncoder) Encode(buf []byte, e *Entry) []byte {
+	host := enc.Host
+	if host == "" {
+		host = hostname
+	}
+	buf = append(buf, `{"version":"1.1","host":`...)
+	buf = appendJSONString(buf, host)
+	buf = append(buf, `,"short_message":`...)
+	buf = appendJSONString(buf, e.Message)
+	buf = append(buf, `,"timestamp":`...)
+	buf = strconv.AppendFloat(buf, float64(e.Time.UnixMicro())/1e6, 'f', 6, 64)
+	buf = append(buf, `,"level":`...)
+	buf = strconv.AppendInt(buf, int64(syslogSeverity(e.Level)), 10)
+	for _, f := range e.Fields {
+		buf = append(buf, ',', '"', '_')
+		buf = appendGELFKey(buf, f.Key)
+		buf = append(buf, '"', ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	return append(buf, '}')
+}
+
+// appendGELFKey appends an additional field name, which may only contain letters, digits,
+// underscores, dashes and dots.  "id" is reserved by GELF so is written as "id_".
+func appendGELFKey(buf []byte, key string) []byte {
+	if key == "id" {
+		return append(buf, "id_"...)
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.') {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// NewGELFWriter returns a Shipper sending records to a GELF input at addr over network, which is
+// "udp" or "tcp" (or their variants).  Over UDP records larger than a datagram are chunked, over TCP
+// they are terminated with a null byte.  Use it with a GELFEncoder:
+//
+//	w, err := log.NewGELFWriter("udp", "graylog:12201", log.ShipConfig{})
+//	logger := log.New(log.Output(w), log.Format(log.GELFEncoder{}))
+func NewGELFWriter(network, addr string, cfg ShipConfig) (*Shipper, error) {
+	t, err := newConnTransport(network, addr, func(buf, rec []byte) []byte {
+		buf = append(buf, rec...)
+		return append(buf, 0)
+	}, writeGELFChunks)
+	if err != nil {
+		return nil, err
+	}
+	return newShipper(t, cfg), nil
+}
+
+// writeGELFChunks writes rec as a single datagram, or as a sequence of chunks if it is too large.
+func writeGELFChunks(conn net.Conn, rec []byte) error {
+	if len(rec) <= gelfChunkSize {
+		_, err := conn.Write(rec)
+		return err
+	}
+	data := gelfChunkSize - gelfChunkHeader
+	count := (len(rec) + data - 1) / data
+	if count > gelfMaxChunks {
+		return errGELFTooLarge
+	}
+	chunk := make([]byte, gelfChunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk[11] = byte(count)
+	for i := 0; i < count; i++ {
+		chunk[10] = byte(i)
+		n := copy(chunk[gelfChunkHeader:], rec[i*data:])
+		if _, err := conn.Write(chunk[:gelfChunkHeader+n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGELFWriter(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	w, err := NewGELFWriter("udp", pc.LocalAddr().String(), ShipConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	logger := New(Output(w), Format(GELFEncoder{Host: "web-1"}))
+	Error(logger, "failed", "id", 7, "user name", "gopher")
+	logger.Log("large", "body", strings.Repeat("x", 20000))
+	w.Flush()
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, gelfChunkSize)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(b[:n], &msg); err != nil {
+		t.Fatalf("expected a JSON datagram, got %q: %v", b[:n], err)
+	}
+	for k, v := range map[string]any{"version": "1.1", "host": "web-1", "short_message": "failed", "level": 3.0, "_id_": 7.0, "_user_name": "gopher"} {
+		if msg[k] != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, msg[k])
+		}
+	}
+
+	var large []byte
+	for i := 0; i < 3; i++ {
+		n, _, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b[0] != 0x1e || b[1] != 0x0f || b[10] != byte(i) || b[11] != 3 {
+			t.Fatalf("unexpected chunk header % x", b[:gelfChunkHeader])
+		}
+		large = append(large, b[gelfChunkHeader:n]...)
+	}
+	if !json.Valid(large) {
+		t.Errorf("expected chunks to reassemble into JSON, got %d bytes", len(large))
+	}
+}
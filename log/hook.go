@@ -0,0 +1,210 @@
+package log
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HookStage decides when a Hook runs.
+type HookStage int
+
+const (
+	// BeforeEncode hooks run once the fields of an entry are complete, before redaction, and may
+	// change the entry, e.g. to add fields.
+	BeforeEncode HookStage = iota
+	// AfterEncode hooks run once the entry has been written, and are passed the encoded record.
+	// They must not change the entry.
+	AfterEncode
+)
+
+// Hook is a callback run for entries written by a logger created with the Hooks option.  Entries are
+// reused once they have been written, so Run must not keep the entry, its fields or the encoded record
+// after it returns.  Hooks are called on the goroutine that logged the entry, so should be quick.
+type Hook struct {
+	Stage HookStage
+	// Match selects the entries the hook runs for, all entries if nil.
+	Match func(e *Entry) bool
+	// Run is called with the entry, and for AfterEncode hooks the encoded record.
+	Run func(e *Entry, encoded []byte)
+}
+
+// Hooks adds hooks run, in order, for the entries that are written.  Entries below the minimum level
+// are discarded before any hook runs.
+func Hooks(hooks ...Hook) Option {
+	return func(l *logger) {
+		for _, h := range hooks {
+			if h.Run == nil {
+				continue
+			}
+			if h.Stage == AfterEncode {
+				l.afterHooks = append(l.afterHooks, h)
+			} else {
+				l.beforeHooks = append(l.beforeHooks, h)
+			}
+		}
+	}
+}
+
+// MatchLevel returns a Hook.Match function selecting entries at or above level.
+func MatchLevel(level Level) func(e *Entry) bool {
+	return func(e *Entry) bool {
+		return e.Level >= level
+	}
+}
+
+func runHooks(hooks []Hook, e *Entry, encoded []byte) {
+	for _, h := range hooks {
+		if h.Match == nil || h.Match(e) {
+			h.Run(e, encoded)
+		}
+	}
+}
+
+// Enrich returns a hook that adds a field with key and the value returned by fn to every entry,
+// for values that change between entries such as the number of goroutines.
+func Enrich(key string, fn func() any) Hook {
+	return Hook{
+		Stage: BeforeEncode,
+		Run: func(e *Entry, _ []byte) {
+			e.Fields = append(e.Fields, Field{Key: key, Value: fn()})
+		},
+	}
+}
+
+// GoroutineCount returns a hook that adds the number of goroutines to every entry under "goroutines".
+func GoroutineCount() Hook {
+	return Enrich("goroutines", func() any { return runtime.NumGoroutine() })
+}
+
+// Forward returns a hook that passes a copy of every entry at or above level to fn once it has been
+// written, e.g. to raise an alert for errors.  The copy may be kept by fn.
+func Forward(level Level, fn func(e Entry)) Hook {
+	return Hook{
+		Stage: AfterEncode,
+		Match: MatchLevel(level),
+		Run: func(e *Entry, _ []byte) {
+			entry := *e
+			entry.Fields = append([]Field(nil), e.Fields...)
+			fn(entry)
+		},
+	}
+}
+
+// DefaultCounterName is the metric name used by NewCounter when name is empty.
+const DefaultCounterName = "log_entries_total"
+
+// Counter counts the entries written by level and message, and exposes the counts in the Prometheus
+// text format.  Add it to a logger with Hooks(c.Hook()) and mount it on a metrics route:
+//
+//	entries := log.NewCounter("")
+//	logger := log.New(log.Hooks(entries.Hook()))
+//	mux.Handle("/metrics/log", entries)
+//
+// Each distinct message is a separate series, so messages should be constant, with the details in fields.
+// It is safe for concurrent use.
+type Counter struct {
+	name string
+
+	mu     sync.Mutex
+	counts map[counterKey]uint64
+}
+
+type counterKey struct {
+	level Level
+	msg   string
+}
+
+// NewCounter returns a Counter exposing the metric name, or DefaultCounterName if name is empty.
+func NewCounter(name string) *Counter {
+	if name == "" {
+		name = DefaultCounterName
+	}
+	return &Counter{name: name, counts: make(map[counterKey]uint64)}
+}
+
+// Hook returns the hook that counts entries.
+func (c *Counter) Hook() Hook {
+	return Hook{
+		Stage: AfterEncode,
+		Run: func(e *Entry, _ []byte) {
+			c.mu.Lock()
+			c.counts[counterKey{e.Level, e.Message}]++
+			c.mu.Unlock()
+		},
+	}
+}
+
+// Count returns the number of entries written at level with msg.
+func (c *Counter) Count(level Level, msg string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[counterKey{level, msg}]
+}
+
+// WriteTo writes the counts to w in the Prometheus text exposition format, sorted by level and message.
+func (c *Counter) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	keys := make([]counterKey, 0, len(c.counts))
+	for k := range c.counts {
+		keys = append(keys, k)
+	}
+	counts := make(map[counterKey]uint64, len(keys))
+	for _, k := range keys {
+		counts[k] = c.counts[k]
+	}
+	c.mu.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].level != keys[j].level {
+			return keys[i].level < keys[j].level
+		}
+		return keys[i].msg < keys[j].msg
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	cw.WriteString("# HELP " + c.name + " Number of log entries written, by level and message.\n")
+	cw.WriteString("# TYPE " + c.name + " counter\n")
+	for _, k := range keys {
+		cw.WriteString(c.name + `{level="` + escapeLabel(k.level.String()) + `",msg="` + escapeLabel(k.msg) + `"} `)
+		cw.WriteString(strconv.FormatUint(counts[k], 10) + "\n")
+	}
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP writes the counts in the Prometheus text exposition format.
+func (c *Counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// escapeLabel escapes a Prometheus label value.
+func escapeLabel(s string) string {
+	if !strings.ContainsAny(s, "\\\"\n") {
+		return s
+	}
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// countingWriter counts the bytes written and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) WriteString(s string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.WriteString(s)
+	cw.n += int64(n)
+	cw.err = err
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var buf bytes.Buffer
+	counter := NewCounter("")
+	var alerts []Entry
+	var encoded []string
+	logger := New(Output(&buf), Redact(DefaultRedaction), Hooks(
+		GoroutineCount(),
+		Enrich("password", func() any { return "hunter2" }),
+		counter.Hook(),
+		Forward(LevelError, func(e Entry) { alerts = append(alerts, e) }),
+		Hook{
+			Stage: AfterEncode,
+			Match: func(e *Entry) bool { return e.Message == "started" },
+			Run:   func(_ *Entry, b []byte) { encoded = append(encoded, string(b)) },
+		},
+	))
+	logger.Log("started")
+	logger.Log("request")
+	logger.Log("request")
+	Error(logger, `query "failed"`, "attempt", 3)
+
+	if !strings.Contains(buf.String(), `"goroutines":`) || strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("expected enriched and redacted entries, got %s", buf.String())
+	}
+	if len(alerts) != 1 || alerts[0].Message != `query "failed"` || alerts[0].Fields[0] != (Field{"attempt", 3}) {
+		t.Errorf("expected the error to be forwarded, got %+v", alerts)
+	}
+	if len(encoded) != 1 || !strings.HasPrefix(encoded[0], `{"timestamp":`) {
+		t.Errorf("expected the encoded record of the matching entry, got %q", encoded)
+	}
+
+	var metrics bytes.Buffer
+	counter.WriteTo(&metrics)
+	want := `# HELP log_entries_total Number of log entries written, by level and message.
+# TYPE log_entries_total counter
+log_entries_total{level="info",msg="request"} 2
+log_entries_total{level="info",msg="started"} 1
+log_entries_total{level="error",msg="query \"failed\""} 1
+`
+	if metrics.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, metrics.String())
+	}
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout bounds each request made by a writer from NewHTTPWriter.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// httpTransport posts each batch of records to a URL as a JSON document built by body.
+type httpTransport struct {
+	url    string
+	client *http.Client
+	header http.Header
+	body   func(buf *bytes.Buffer, records [][]byte)
+	buf    bytes.Buffer
+}
+
+func newHTTPTransport(url string, header http.Header, client *http.Client, body func(*bytes.Buffer, [][]byte)) *httpTransport {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	return &httpTransport{url: url, client: client, header: header.Clone(), body: body}
+}
+
+// NewHTTPWriter returns a Shipper posting batches of records to url as a JSON array, with the
+// content type "application/json" and any headers in header, e.g. for authorization.  Each record
+// must be a JSON object, so it should be used with a JSONEncoder.  Responses with a 429 or 5xx
+// status are retried, other non-2xx responses drop the batch.  If client is nil one with a timeout
+// of DefaultHTTPTimeout is used.
+func NewHTTPWriter(url string, header http.Header, client *http.Client, cfg ShipConfig) *Shipper {
+	return newShipper(newHTTPTransport(url, header, client, writeJSONArray), cfg)
+}
+
+// writeJSONArray writes records, each a JSON value, as a JSON array.
+func writeJSONArray(buf *bytes.Buffer, records [][]byte) {
+	buf.WriteByte('[')
+	for i, rec := range records {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(bytes.TrimRight(rec, "\n"))
+	}
+	buf.WriteByte(']')
+}
+
+// send posts records as a single request, so either all or none of them are delivered.
+func (t *httpTransport) send(records [][]byte) (int, int, error) {
+	if err := t.post(records); err != nil {
+		return 0, 0, err
+	}
+	return len(records), 0, nil
+}
+
+func (t *httpTransport) post(records [][]byte) error {
+	t.buf.Reset()
+	t.body(&t.buf, records)
+
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(t.buf.Bytes()))
+	if err != nil {
+		return permanentError{fmt.Errorf("log: creating request: %w", err)}
+	}
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("log: posting to %s: %w", t.url, err)
+	}
+	// drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("log: posting to %s: %s", t.url, resp.Status)
+	default:
+		return permanentError{fmt.Errorf("log: posting to %s: %s", t.url, resp.Status)}
+	}
+}
+
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTPWriter(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]map[string]any
+	down := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var batch []map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, batch)
+	}))
+	defer srv.Close()
+
+	spill := filepath.Join(t.TempDir(), "spill")
+	w := NewHTTPWriter(srv.URL, http.Header{"Authorization": {"Bearer token"}}, nil, ShipConfig{
+		BatchSize:      2,
+		MaxRetries:     1,
+		RetryBaseDelay: time.Millisecond,
+		SpillPath:      spill,
+	})
+	logger := New(Output(w))
+	logger.Log("first")
+	logger.Log("second")
+	w.Flush()
+	if got := w.Stats(); got.Spilled != 2 || got.Sent != 0 {
+		t.Fatalf("expected the batch to be spilled whilst the endpoint is down, got %+v", got)
+	}
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	logger.Log("third")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Stats(); got.Sent != 3 {
+		t.Errorf("expected 3 records sent, got %+v", got)
+	}
+	var msgs []any
+	for _, batch := range batches {
+		for _, rec := range batch {
+			msgs = append(msgs, rec["msg"])
+		}
+	}
+	// spilled records are resent once a send succeeds.
+	if want := []any{"third", "first", "second"}; !reflect.DeepEqual(msgs, want) {
+		t.Errorf("expected %v in order, got %v", want, msgs)
+	}
+	if _, err := os.Stat(spill); !os.IsNotExist(err) {
+		t.Errorf("expected the spill file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the severity of a log entry.  The values match those of log/slog so that levels
+// can be converted between the two packages without a lookup.
+type Level int
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// String returns the lower case name of the level, e.g. "info".
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ParseLevel returns the level named by s, ignoring case.  "warning" is accepted for LevelWarn.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("log: unknown level %q", s)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *Level) UnmarshalText(b []byte) error {
+	lvl, err := ParseLevel(string(b))
+	if err != nil {
+		return err
+	}
+	*l = lvl
+	return nil
+}
+
+// Leveler is implemented by anything that provides a minimum level, such as a Level or a LevelVar.
+type Leveler interface {
+	Level() Level
+}
+
+// Level returns l, so that a Level can be used as a Leveler.
+func (l Level) Level() Level {
+	return l
+}
+
+// LevelVar is a Level that can be changed safely whilst it is being read, e.g. by a logger created
+// with MinLevel.  The zero value is LevelInfo.
+type LevelVar struct {
+	val atomic.Int64
+}
+
+// Level returns the current level.
+func (v *LevelVar) Level() Level {
+	return Level(v.val.Load())
+}
+
+// Set changes the level.
+func (v *LevelVar) Set(level Level) {
+	v.val.Store(int64(level))
+}
+
+// String returns the name of the current level.
+func (v *LevelVar) String() string {
+	return v.Level().String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v *LevelVar) MarshalText() ([]byte, error) {
+	return v.Level().MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *LevelVar) UnmarshalText(b []byte) error {
+	var lvl Level
+	if err := lvl.UnmarshalText(b); err != nil {
+		return err
+	}
+	v.Set(lvl)
+	return nil
+}
+
+// LevelLogger is implemented by loggers that understand severity.  Loggers that only implement
+// Logger can still be used with the level functions, the level is passed as a field instead.
+type LevelLogger interface {
+	Logger
+	LogLevel(level Level, msg string, fields ...interface{}) error
+	Enabled(level Level) bool
+}
+
+// Debug logs msg at LevelDebug.
+func Debug(l Logger, msg string, fields ...interface{}) error {
+	return LogLevel(l, LevelDebug, msg, fields...)
+}
+
+// Info logs msg at LevelInfo.
+func Info(l Logger, msg string, fields ...interface{}) error {
+	return LogLevel(l, LevelInfo, msg, fields...)
+}
+
+// Warn logs msg at LevelWarn.
+func Warn(l Logger, msg string, fields ...interface{}) error {
+	return LogLevel(l, LevelWarn, msg, fields...)
+}
+
+// Error logs msg at LevelError.
+func Error(l Logger, msg string, fields ...interface{}) error {
+	return LogLevel(l, LevelError, msg, fields...)
+}
+
+// LogLevel logs msg at the given level.  If l is not a LevelLogger the level is added to the
+// fields under the "level" key and passed to Log.
+func LogLevel(l Logger, level Level, msg string, fields ...interface{}) error {
+	if ll, ok := l.(LevelLogger); ok {
+		return ll.LogLevel(level, msg, fields...)
+	}
+	return l.Log(msg, append(fields[:len(fields):len(fields)], "level", level.String())...)
+}
+
+// Enabled reports whether l will log entries at level.  Loggers that are not a LevelLogger
+// are assumed to log everything.
+func Enabled(l Logger, level Level) bool {
+	if ll, ok := l.(LevelLogger); ok {
+		return ll.Enabled(level)
+	}
+	return true
+}
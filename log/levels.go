@@ -0,0 +1,206 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Levels holds a root LevelVar and a LevelVar for each named part of an application, so that their
+// levels can be changed independently whilst it is running.  Pass the Levels to MinLevel, so that
+// loggers created from it with Named use the level of their name, and mount it, as an http.Handler,
+// on an admin route:
+//
+//	levels := log.NewLevels(log.LevelInfo)
+//	levels.Var("db").Set(log.LevelDebug)
+//	logger := log.New(log.MinLevel(levels))
+//	dbLogger := log.Named(logger, "db.migrate") // logs at debug, like everything under "db".
+//	mux.Handle("/admin/log/level", levels)
+//
+// Root or Var can also be passed to MinLevel to tie a logger to a single level.
+type Levels struct {
+	root LevelVar
+
+	mu   sync.RWMutex
+	vars map[string]*LevelVar
+}
+
+// NewLevels returns Levels with the root level set to root.
+func NewLevels(root Level) *Levels {
+	ls := &Levels{vars: make(map[string]*LevelVar)}
+	ls.root.Set(root)
+	return ls
+}
+
+// Level returns the root level, so that Levels can be passed to MinLevel.
+func (ls *Levels) Level() Level {
+	return ls.root.Level()
+}
+
+// For returns the level of the named logger name: the level set for the longest prefix of name, split
+// at dots, or the root level if there is none.  "db.migrate" uses the level of "db.migrate" if it has
+// been created with Var, then that of "db".
+func (ls *Levels) For(name string) Level {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.forLocked(name)
+}
+
+// forLocked must be called with ls.mu held.
+func (ls *Levels) forLocked(name string) Level {
+	for name != "" {
+		if v, ok := ls.vars[name]; ok {
+			return v.Level()
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return ls.root.Level()
+}
+
+// Root returns the LevelVar of loggers without a name.
+func (ls *Levels) Root() *LevelVar {
+	return &ls.root
+}
+
+// Var returns the LevelVar for name, creating it at the level it currently inherits, see For, if it
+// does not exist.
+// The empty name returns Root.
+func (ls *Levels) Var(name string) *LevelVar {
+	if name == "" {
+		return &ls.root
+	}
+	ls.mu.RLock()
+	v, ok := ls.vars[name]
+	ls.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if v, ok := ls.vars[name]; ok {
+		return v
+	}
+	v = &LevelVar{}
+	v.Set(ls.forLocked(name))
+	ls.vars[name] = v
+	return v
+}
+
+// lookup returns the LevelVar for name if it exists.
+func (ls *Levels) lookup(name string) (*LevelVar, bool) {
+	if name == "" {
+		return &ls.root, true
+	}
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	v, ok := ls.vars[name]
+	return v, ok
+}
+
+// Names returns the names of the LevelVars created with Var, sorted.
+func (ls *Levels) Names() []string {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	names := make([]string, 0, len(ls.vars))
+	for name := range ls.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// levelState is the JSON body read and written by Levels.ServeHTTP.
+type levelState struct {
+	Logger  string           `json:"logger,omitempty"`
+	Level   *Level           `json:"level"`
+	Loggers map[string]Level `json:"loggers,omitempty"`
+}
+
+// ServeHTTP reports and changes levels as JSON.  The "logger" query parameter selects a named level,
+// which applies to every name it prefixes, without it the root level is used.
+//
+// GET responds with the level, and for the root the level of each named logger:
+//
+//	{"level":"info","loggers":{"db":"debug"}}
+//
+// PUT sets the level from a body such as {"level":"debug"}, creating a named level if required, and
+// responds as GET.
+func (ls *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("logger")
+	switch r.Method {
+	case http.MethodGet:
+		if _, ok := ls.lookup(name); !ok {
+			http.Error(w, "log: unknown logger "+name, http.StatusNotFound)
+			return
+		}
+	case http.MethodPut:
+		var body levelState
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil {
+			http.Error(w, "log: invalid level: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body.Level == nil {
+			http.Error(w, "log: missing level", http.StatusBadRequest)
+			return
+		}
+		ls.Var(name).Set(*body.Level)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, _ := ls.lookup(name)
+	level := v.Level()
+	state := levelState{Logger: name, Level: &level}
+	if name == "" {
+		state.Loggers = make(map[string]Level)
+		for _, n := range ls.Names() {
+			state.Loggers[n] = ls.Var(n).Level()
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// toggleOn lowers v to LevelDebug when debug is received, and restores the level it had before when
+// restore is received, until stop is called.
+func (v *LevelVar) toggleOn(debug, restore os.Signal) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, debug, restore)
+	go func() {
+		previous := v.Level()
+		for {
+			select {
+			case s := <-sig:
+				if s == debug {
+					if current := v.Level(); current != LevelDebug {
+						previous = current
+					}
+					v.Set(LevelDebug)
+				} else {
+					v.Set(previous)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sig)
+			close(done)
+		})
+	}
+}
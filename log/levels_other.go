@@ -0,0 +1,8 @@
+//go:build !unix
+
+package log
+
+// ToggleOnSignal does nothing on platforms without SIGUSR1 and SIGUSR2.
+func (v *LevelVar) ToggleOnSignal() (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	levels := NewLevels(LevelInfo)
+	logger := New(Output(io.Discard), MinLevel(levels.Root()))
+	db := New(Output(io.Discard), MinLevel(levels.Var("db")))
+	srv := httptest.NewServer(levels)
+	defer srv.Close()
+
+	do := func(method, query, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+query, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(b))
+	}
+
+	if code, body := do(http.MethodPut, "?logger=db", `{"level":"debug"}`); code != http.StatusOK || body != `{"logger":"db","level":"debug"}` {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	if !db.Enabled(LevelDebug) || logger.Enabled(LevelDebug) {
+		t.Errorf("expected only the db logger to have debug enabled")
+	}
+	if code, body := do(http.MethodGet, "", ""); code != http.StatusOK || body != `{"level":"info","loggers":{"db":"debug"}}` {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	if code, _ := do(http.MethodPut, "", `{"level":"loud"}`); code != http.StatusBadRequest {
+		t.Errorf("expected %d for an unknown level, got %d", http.StatusBadRequest, code)
+	}
+	if code, _ := do(http.MethodGet, "?logger=cache", ""); code != http.StatusNotFound {
+		t.Errorf("expected %d for an unknown logger, got %d", http.StatusNotFound, code)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package log
+
+import "syscall"
+
+// ToggleOnSignal sets the level to LevelDebug when the process receives SIGUSR1, and restores the
+// level it had before when it receives SIGUSR2, until stop is called.
+func (v *LevelVar) ToggleOnSignal() (stop func()) {
+	return v.toggleOn(syscall.SIGUSR1, syscall.SIGUSR2)
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestToggleOnSignal(t *testing.T) {
+	var v LevelVar
+	stop := v.ToggleOnSignal()
+	defer stop()
+	p, _ := os.FindProcess(os.Getpid())
+	for _, tt := range []struct {
+		sig  os.Signal
+		want Level
+	}{{syscall.SIGUSR1, LevelDebug}, {syscall.SIGUSR2, LevelInfo}} {
+		p.Signal(tt.sig)
+		deadline := time.Now().Add(5 * time.Second)
+		for v.Level() != tt.want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := v.Level(); got != tt.want {
+			t.Errorf("expected level %s after %v, got %s", tt.want, tt.sig, got)
+		}
+	}
+}
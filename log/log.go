@@ -6,8 +6,6 @@ whilst allowing the caller to leverage those packages if required.
 package log
 
 import (
-	"encoding/json"
-	"fmt"
 	"io"
 	"os"
 	"sync"
@@ -24,70 +22,153 @@ type Logger interface {
 // logger is a very simple implementation of the  Logger interface.
 // It provides structured json logging by default.
 type logger struct {
-	writer io.Writer  // destinatation for log output.
-	mu     sync.Mutex //  mutex prevents concurrent writes to the output.
+	writer  io.Writer      // destinatation for log output.
+	mu      sync.Mutex     //  mutex prevents concurrent writes to the output.
+	level   Leveler        // minimum level that will be written, consulted on every entry.
+	encoder Encoder        // formats entries before they are written.
+	auto    bool           // the encoder is chosen to suit the writer, as none was set with Format.
+	config  EncoderConfig  // time format and key names applied to the built in encoders.
+	loc     *time.Location // time zone of the entry timestamps.
+	fields  []Field        // base fields written before the fields of every entry.
+
+	redaction *Redaction // nil unless the Redact option is used.
+
+	caller      bool  // add the caller of each entry, set by AddCaller.
+	stacktrace  bool  // add a stack trace to entries at or above stackLevel, set by AddStacktrace.
+	stackLevel  Level // minimum level of entries given a stack trace.
+	errorChains bool  // write error fields as their chain, set by ErrorChains.
+
+	beforeHooks []Hook // run before an entry is encoded, set by Hooks.
+	afterHooks  []Hook // run after an entry is written, set by Hooks.
 }
 
-// New creates a new logger instance.
-// It assumes StdOut is the default location, may take this as an argument later.
-func New() *logger {
-	return &logger{
+// New creates a new logger instance configured by opts.
+// Without options it writes JSON entries at LevelInfo and above to StdOut, with UTC timestamps.
+func New(opts ...Option) *logger {
+	l := &logger{
 		writer: os.Stdout,
 		mu:     sync.Mutex{},
+		level:  LevelInfo,
+		auto:   true,
+		loc:    time.UTC,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.auto {
+		l.encoder = defaultEncoder(l.writer)
 	}
+	l.encoder = l.config.apply(l.encoder)
+	return l
 }
 
 // DefaultLogger exposes a pre-configfured implementation of the Logger interface that can used when the caller does not require more fine grained control over
 // their logger instance.  Similar to the stdlib pattern.
 var DefaultLogger Logger = New()
 
-// Log will print the msg to the loggers writer.
-// Fields are key/value pairs that will be logged to provide additional context.  If there is an odd number of pairs, they will be silently dropped.
+// Log will print the msg to the loggers writer at LevelInfo.
+// Fields are key/value pairs that will be logged to provide additional context.  Arguments that do not form
+// a pair, such as a dangling value or a key that is not a string, are logged under BadKey rather than dropped.
+// If a key is repeated the last value wins, written at the position of its last occurrence.  Fields that
+// collide with the timestamp, message or level keys are written with a "fields." prefix.
 func (l *logger) Log(msg string, fields ...any) error {
-	entry := map[string]string{
-		"msg":       msg,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	}
-	if len(fields)%2 == 0 {
-		for i := 0; i < len(fields); {
-			fieldName, ok := fields[i].(string)
-			if ok {
-				entry[fieldName] = fmt.Sprint(fields[i+1])
-			}
-			i = i + 2
-		}
+	return l.LogLevel(LevelInfo, msg, fields...)
+}
+
+// Debug logs msg at LevelDebug.
+func (l *logger) Debug(msg string, fields ...any) error {
+	return l.LogLevel(LevelDebug, msg, fields...)
+}
+
+// Info logs msg at LevelInfo.
+func (l *logger) Info(msg string, fields ...any) error {
+	return l.LogLevel(LevelInfo, msg, fields...)
+}
+
+// Warn logs msg at LevelWarn.
+func (l *logger) Warn(msg string, fields ...any) error {
+	return l.LogLevel(LevelWarn, msg, fields...)
+}
+
+// Error logs msg at LevelError.
+func (l *logger) Error(msg string, fields ...any) error {
+	return l.LogLevel(LevelError, msg, fields...)
+}
+
+// Enabled reports whether entries at level will be written.
+func (l *logger) Enabled(level Level) bool {
+	return level >= l.level.Level()
+}
+
+// LogLevel will print the msg to the loggers writer if level is at or above the loggers minimum level.
+// Suppressed entries return before any encoding is done.
+func (l *logger) LogLevel(level Level, msg string, fields ...any) error {
+	if !l.Enabled(level) {
+		return nil
 	}
+	return l.write(level, msg, "", fields)
+}
 
-	bytes, err := json.Marshal(entry)
-	if err != nil {
-		return fmt.Errorf("log: marshalling json: %w", err)
+// write encodes and writes an entry, with a NameKey field after the base fields if name is set.
+func (l *logger) write(level Level, msg, name string, fields []any) error {
+	e := getEntry()
+	e.Time = time.Now().In(l.loc)
+	e.Level = level
+	e.Message = msg
+	e.Fields = append(e.Fields, l.fields...)
+	if name != "" {
+		e.Fields = append(e.Fields, Field{Key: NameKey, Value: name})
+	}
+	e.Fields = appendFields(e.Fields, fields)
+	if l.beforeHooks != nil {
+		runHooks(l.beforeHooks, e, nil)
 	}
+	e.Fields = dedupe(e.Fields)
+	if l.redaction != nil {
+		l.redaction.apply(e)
+	}
+	if l.caller || l.stacktrace || l.errorChains {
+		l.annotate(e)
+	}
+
+	l.mu.Lock()
+	enc, w := l.encoder, l.writer
+	l.mu.Unlock()
+
+	buf := getBuffer()
+	*buf = enc.Encode(*buf, e)
 
 	l.mu.Lock()
-	// write the output, each entry is on a new line by default.
-	_, err = l.writer.Write(append(bytes, byte('\n')))
+	// each entry is on a new line by default, the encoder writes the line ending.
+	_, err := w.Write(*buf)
 	l.mu.Unlock()
 
+	if l.afterHooks != nil {
+		runHooks(l.afterHooks, e, *buf)
+	}
+	putEntry(e)
+	putBuffer(buf)
 	return err
 }
 
 // SetOutput exists so that the log destination can be safely changed whilst being protected
 // by the mutex.  Originally this was not going to be included, as it seems YAGNI, however,
 // it was necessary for testing.
+// If no encoder was set with Format, the encoder is chosen again to suit w.
 func (l *logger) SetOutput(w io.Writer) {
 	l.mu.Lock()
 	l.writer = w
+	if l.auto {
+		l.encoder = l.config.apply(defaultEncoder(w))
+	}
 	l.mu.Unlock()
 }
 
 // WithFields wraps a logger and holds a set of fields that should be logged on every call to Log.
-// It expects an even number of arguments, odd numbers are silently dropped.
+// Arguments that do not form a key/value pair are kept under BadKey, following the rules of Log.
 func WithFields(l Logger, fields ...interface{}) Logger {
-	if len(fields)%2 != 0 {
-		return l
-	}
 	return fieldLogger{
-		fields: fields,
+		fields: normalizeFields(fields),
 		Logger: l,
 	}
 }
@@ -98,13 +179,37 @@ type fieldLogger struct {
 }
 
 // Log calls the underlying loggers Log method and passes any stored fields in addition
-// to the ones supplied.
-// Behvaiour of duplicate keys will vary on the underlying loggers behaviour.  Potentially
-// should do something to enforce a rule in future.
+// to the ones supplied.  The supplied fields come last, so they win over stored fields
+// with the same key.
 func (f fieldLogger) Log(msg string, fields ...interface{}) error {
-	if len(fields)%2 != 0 {
-		return f.Logger.Log(msg, f.fields...)
-	}
-	fields = append(f.fields, fields...)
+	fields = append(f.fields[:len(f.fields):len(f.fields)], normalizeFields(fields)...)
 	return f.Logger.Log(msg, fields...)
 }
+
+// LogLevel passes the stored fields, in addition to the ones supplied, to the underlying logger at level.
+func (f fieldLogger) LogLevel(level Level, msg string, fields ...interface{}) error {
+	fields = append(f.fields[:len(f.fields):len(f.fields)], normalizeFields(fields)...)
+	return LogLevel(f.Logger, level, msg, fields...)
+}
+
+// Enabled reports whether the underlying logger will log entries at level.
+func (f fieldLogger) Enabled(level Level) bool {
+	return Enabled(f.Logger, level)
+}
+
+// EnabledFor passes the name of a Named logger through to the underlying logger.
+func (f fieldLogger) EnabledFor(name string, level Level) bool {
+	if nl, ok := f.Logger.(NamedLevelLogger); ok {
+		return nl.EnabledFor(name, level)
+	}
+	return Enabled(f.Logger, level)
+}
+
+// LogFor passes the name of a Named logger, and the stored fields, through to the underlying logger.
+func (f fieldLogger) LogFor(name string, level Level, msg string, fields ...any) error {
+	fields = append(f.fields[:len(f.fields):len(f.fields)], normalizeFields(fields)...)
+	if nl, ok := f.Logger.(NamedLevelLogger); ok {
+		return nl.LogFor(name, level, msg, fields...)
+	}
+	return LogLevel(f.Logger, level, msg, append([]any{NameKey, name}, fields...)...)
+}
@@ -2,7 +2,19 @@ package log
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 func BenchmarkLog(b *testing.B) {
@@ -16,3 +28,365 @@ func BenchmarkLog(b *testing.B) {
 	}
 
 }
+
+func TestLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(MinLevel(LevelWarn))
+	logger.SetOutput(&buf)
+
+	logger.Debug("debug")
+	logger.Log("info")
+	if buf.Len() != 0 {
+		t.Errorf("expected entries below warn to be suppressed, got %q", buf.String())
+	}
+
+	Error(WithFields(logger, "key", "value"), "error")
+	if got := buf.String(); !strings.Contains(got, `"level":"error"`) || !strings.Contains(got, `"key":"value"`) {
+		t.Errorf("expected error entry with field, got %q", got)
+	}
+}
+
+func BenchmarkLogSuppressed(b *testing.B) {
+	logger := New(MinLevel(LevelInfo))
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Debug("write something")
+	}
+}
+
+type logValuer struct{}
+
+func (logValuer) LogValue() any { return map[string]int{"nested": 1} }
+
+func TestLogFieldTypes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New()
+	logger.SetOutput(&buf)
+
+	ts := time.Date(2023, 1, 28, 12, 0, 0, 500, time.UTC)
+	logger.Log("typed",
+		"int", 42,
+		"bool", true,
+		"duration", time.Second,
+		"err", errors.New("boom"),
+		"time", ts,
+		"valuer", logValuer{},
+		"struct", struct{ Name string }{"gopher"},
+		"func", func() {},
+	)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshalling entry: %v", err)
+	}
+	want := map[string]any{
+		"int":      float64(42),
+		"bool":     true,
+		"duration": float64(time.Second),
+		"err":      "boom",
+		"time":     "2023-01-28T12:00:00.0000005Z",
+		"valuer":   map[string]any{"nested": float64(1)},
+		"struct":   map[string]any{"Name": "gopher"},
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			t.Errorf("field %q: expected %#v, got %#v", k, v, got[k])
+		}
+	}
+	if _, ok := got["func"].(string); !ok {
+		t.Errorf("expected unmarshallable value to fall back to a string, got %#v", got["func"])
+	}
+}
+
+func TestLogFieldOrder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New()
+	logger.SetOutput(&buf)
+
+	logger.Log("ordered", "z", 1, "a", 2, "m", 3)
+	got := buf.String()
+	prefix := `{"timestamp":"`
+	suffix := `","msg":"ordered","level":"info","z":1,"a":2,"m":3}` + "\n"
+	if !strings.HasPrefix(got, prefix) || !strings.HasSuffix(got, suffix) {
+		t.Errorf("expected %s...%s, got %s", prefix, suffix, got)
+	}
+}
+
+func TestLogEscaping(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New()
+	logger.SetOutput(&buf)
+
+	msg := "quote \" backslash \\ newline \n control \x01 invalid \xff separator \u2028 unicode é"
+	logger.Log(msg, "float", 1e21, "nan", math.NaN())
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshalling %q: %v", buf.String(), err)
+	}
+	if want := strings.Replace(msg, "\xff", "\ufffd", 1); got["msg"] != want {
+		t.Errorf("expected msg %q, got %q", want, got["msg"])
+	}
+	if got["float"] != 1e21 || got["nan"] != "NaN" {
+		t.Errorf("unexpected float encoding in %s", buf.String())
+	}
+}
+
+func TestLogAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items at random under the race detector")
+	}
+	logger := New()
+	logger.SetOutput(io.Discard)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.Log("write something", "key", "value", "count", 7, "ok", true)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+// mapLog is the original map based implementation of logger.Log, kept to benchmark against.
+func mapLog(w io.Writer, msg string, fields ...any) error {
+	entry := map[string]any{
+		"msg":       msg,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		if key, ok := fields[i].(string); ok {
+			entry[key] = fields[i+1]
+		}
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func BenchmarkLogFields(b *testing.B) {
+	logger := New()
+	logger.SetOutput(io.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Log("write something", "key", "value", "count", 7, "ok", true)
+	}
+}
+
+func BenchmarkLogFieldsMap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		mapLog(io.Discard, "write something", "key", "value", "count", 7, "ok", true)
+	}
+}
+
+func TestTextEncoders(t *testing.T) {
+	e := &Entry{
+		Time:    time.Date(2023, 1, 28, 12, 0, 0, 0, time.UTC),
+		Level:   LevelWarn,
+		Message: "disk nearly full",
+		Fields:  []Field{{"path", "/var/log"}, {"free", 0.05}, {"note", "check soon"}, {"err", errors.New("quota")}},
+	}
+
+	t.Run("logfmt", func(t *testing.T) {
+		got := string(LogfmtEncoder{}.Encode(nil, e))
+		want := `time=2023-01-28T12:00:00Z level=warn msg="disk nearly full" path=/var/log free=0.05 note="check soon" err=quota` + "\n"
+		if got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("console", func(t *testing.T) {
+		got := string(ConsoleEncoder{}.Encode(nil, e))
+		want := "WARN  disk nearly full" + strings.Repeat(" ", consoleMessageWidth-len("disk nearly full")) +
+			` path=/var/log free=0.05 note="check soon" err=quota` + "\n"
+		if !strings.HasSuffix(got, want) {
+			t.Errorf("expected suffix %q, got %q", want, got)
+		}
+		colored := string(ConsoleEncoder{Color: true}.Encode(nil, e))
+		if !strings.Contains(colored, colorYellow+"WARN"+colorReset) {
+			t.Errorf("expected colorized level, got %q", colored)
+		}
+	})
+}
+
+func TestNilPointerFields(t *testing.T) {
+	e := &Entry{
+		Time:    time.Date(2023, 1, 28, 12, 0, 0, 0, time.UTC),
+		Message: "x",
+		Fields:  []Field{{"t", (*time.Time)(nil)}, {"err", (*net.OpError)(nil)}},
+	}
+	tests := []struct {
+		name string
+		enc  Encoder
+		want []string
+	}{
+		{name: "json", enc: JSONEncoder{}, want: []string{`"t":null`, `"err":null`}},
+		{name: "logfmt", enc: LogfmtEncoder{}, want: []string{"t=null", "err=null"}},
+		{name: "console", enc: ConsoleEncoder{}, want: []string{"t=null", "err=null"}},
+		{name: "otel", enc: OTelEncoder{}, want: []string{`{"key":"t","value":{"stringValue":"null"}}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(tt.enc.Encode(nil, e))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in %q", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	var buf bytes.Buffer
+	loc := time.FixedZone("CET", 3600)
+	logger := New(
+		Output(&buf),
+		MinLevel(LevelDebug),
+		TimeFormat("2006-01-02T15:04:05Z07:00"),
+		TimeZone(loc),
+		FieldNames("time", "message", "severity"),
+		BaseFields("service", "api", "version", "1.2.3"),
+	)
+	logger.Debug("configured", "key", "value")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshalling %q: %v", buf.String(), err)
+	}
+	if got["message"] != "configured" || got["severity"] != "debug" {
+		t.Errorf("expected overridden field names, got %s", buf.String())
+	}
+	if ts, _ := got["time"].(string); !strings.HasSuffix(ts, "+01:00") {
+		t.Errorf("expected timestamp in CET, got %q", ts)
+	}
+	if !strings.Contains(buf.String(), `"service":"api","version":"1.2.3","key":"value"`) {
+		t.Errorf("expected base fields before entry fields, got %s", buf.String())
+	}
+}
+
+func TestMalformedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		log    func(l Logger)
+		fields string
+	}{
+		{
+			name:   "dangling value",
+			log:    func(l Logger) { l.Log("m", "a", 1, "b") },
+			fields: `"a":1,"!BADKEY":"b"`,
+		},
+		{
+			name:   "non string key",
+			log:    func(l Logger) { l.Log("m", 42, "a", 1) },
+			fields: `"!BADKEY":42,"a":1`,
+		},
+		{
+			name:   "duplicate keys last wins",
+			log:    func(l Logger) { l.Log("m", "a", 1, "b", 2, "a", 3) },
+			fields: `"b":2,"a":3`,
+		},
+		{
+			name:   "reserved key",
+			log:    func(l Logger) { l.Log("m", "msg", "field") },
+			fields: `"fields.msg":"field"`,
+		},
+		{
+			name:   "odd stored fields",
+			log:    func(l Logger) { WithFields(l, "a").Log("m", "b", 2) },
+			fields: `"!BADKEY":"a","b":2`,
+		},
+		{
+			name:   "odd caller fields keep stored fields",
+			log:    func(l Logger) { WithFields(l, "a", 1).Log("m", "b") },
+			fields: `"a":1,"!BADKEY":"b"`,
+		},
+		{
+			name:   "caller fields win over stored fields",
+			log:    func(l Logger) { WithFields(l, "a", 1, "b", 2).Log("m", "a", 3) },
+			fields: `"b":2,"a":3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(New(Output(&buf)))
+			want := `"level":"info",` + tt.fields + "}\n"
+			if got := buf.String(); !strings.HasSuffix(got, want) {
+				t.Errorf("expected suffix %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), New(Output(&buf)))
+	ctx = ContextWithFields(ctx, "request_id", "abc")
+	ctx = ContextWithFields(ctx, "user_id", 7)
+
+	Warn(FromContext(ctx), "deep in the stack", "key", "value")
+	want := `"level":"warn","request_id":"abc","user_id":7,"key":"value"}`
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("expected entry containing %s, got %s", want, got)
+	}
+
+	if got := FromContext(context.Background()); got != DefaultLogger {
+		t.Errorf("expected DefaultLogger from an empty context, got %v", got)
+	}
+}
+
+func TestAdapters(t *testing.T) {
+	t.Run("slog handler writes to Logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		sl := slog.New(NewSlogHandler(New(Output(&buf))))
+		sl.With("service", "api").WithGroup("req").Warn("slow", "ms", 250)
+		sl.Debug("suppressed")
+
+		want := `"msg":"slow","level":"warn","service":"api","req.ms":250}` + "\n"
+		if got := buf.String(); !strings.HasSuffix(got, want) {
+			t.Errorf("expected suffix %s, got %s", want, got)
+		}
+	})
+
+	t.Run("Logger writes to slog handler", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := FromSlog(slog.NewJSONHandler(&buf, nil))
+		Error(l, "failed", "attempt", 2)
+
+		if got := buf.String(); !strings.Contains(got, `"level":"ERROR","msg":"failed","attempt":2`) {
+			t.Errorf("unexpected slog output %s", got)
+		}
+	})
+
+	t.Run("Logger writes to logrus", func(t *testing.T) {
+		var buf bytes.Buffer
+		lr := logrus.New()
+		lr.SetOutput(&buf)
+		lr.SetFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+		l := FromLogrus(logrus.NewEntry(lr))
+		Warn(l, "careful", "count", 3)
+		Debug(l, "suppressed")
+
+		if got, want := buf.String(), `{"count":3,"level":"warning","msg":"careful"}`+"\n"; got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	})
+
+	t.Run("std logger writes to Logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewStdLogger(New(Output(&buf)), LevelError).Print("http: TLS handshake error")
+
+		if got := buf.String(); !strings.Contains(got, `"msg":"http: TLS handshake error","level":"error"`) {
+			t.Errorf("unexpected output %s", got)
+		}
+	})
+}
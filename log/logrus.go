@@ -0,0 +1,52 @@
+package log
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// FromLogrus returns a Logger that writes through the logrus entry e, so that an existing logrus
+// setup, with its hooks and formatters, can sit behind the Logger interface.
+func FromLogrus(e *logrus.Entry) Logger {
+	return logrusLogger{entry: e}
+}
+
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+// Log logs msg at LevelInfo.
+func (l logrusLogger) Log(msg string, fields ...interface{}) error {
+	return l.LogLevel(LevelInfo, msg, fields...)
+}
+
+// LogLevel logs msg with fields at the logrus level matching level.
+func (l logrusLogger) LogLevel(level Level, msg string, fields ...interface{}) error {
+	lvl := logrusLevel(level)
+	if !l.entry.Logger.IsLevelEnabled(lvl) {
+		return nil
+	}
+	f := make(logrus.Fields, len(fields)/2)
+	for _, field := range appendFields(nil, fields) {
+		f[field.Key] = resolve(field.Value)
+	}
+	l.entry.WithFields(f).Log(lvl, msg)
+	return nil
+}
+
+// Enabled reports whether the logrus logger is enabled for level.
+func (l logrusLogger) Enabled(level Level) bool {
+	return l.entry.Logger.IsLevelEnabled(logrusLevel(level))
+}
+
+func logrusLevel(level Level) logrus.Level {
+	switch {
+	case level >= LevelError:
+		return logrus.ErrorLevel
+	case level >= LevelWarn:
+		return logrus.WarnLevel
+	case level >= LevelInfo:
+		return logrus.InfoLevel
+	default:
+		return logrus.DebugLevel
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Package logtest provides a log.Logger that records entries in memory, so that tests can assert what
+was logged without parsing output.
+*/
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goaferlx/go-core/log"
+)
+
+// Entry is a recorded log entry.  Fields are as they were passed to the encoder: paired, deduplicated
+// and, with the log.Redact option, redacted by key and pattern, including base fields and those added
+// by options such as log.AddCaller.  Values are not resolved, so a log.Redactor or log.LogValuer is
+// recorded as it was logged rather than as the value it is encoded as.
+type Entry struct {
+	Time    time.Time
+	Level   log.Level
+	Message string
+	Fields  []log.Field
+}
+
+// Field returns the value of the field with key, and whether it was present.
+func (e Entry) Field(key string) (any, bool) {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// String formats the entry in a logfmt like style for failure messages.
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q", e.Level, e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	return b.String()
+}
+
+// Filter selects entries returned by Logger.Entries.
+type Filter func(Entry) bool
+
+// AtLevel selects entries logged at level.
+func AtLevel(level log.Level) Filter {
+	return func(e Entry) bool { return e.Level == level }
+}
+
+// WithMessage selects entries with the message msg.
+func WithMessage(msg string) Filter {
+	return func(e Entry) bool { return e.Message == msg }
+}
+
+// WithField selects entries with a field key equal to value, as decided by AssertLogged.
+func WithField(key string, value any) Filter {
+	return func(e Entry) bool {
+		got, ok := e.Field(key)
+		return ok && equal(value, got)
+	}
+}
+
+// Logger is a log.LevelLogger that records every entry it writes.  It is built on log.New, so levels,
+// base fields, redaction and the other options behave as they do in production.  It is safe for
+// concurrent use.
+type Logger struct {
+	log.LevelLogger
+	rec *recorder
+}
+
+// New returns a Logger configured by opts, that records entries without writing them anywhere.
+// The Output and Format options are ignored.
+func New(opts ...log.Option) *Logger {
+	return newLogger(&recorder{}, io.Discard, opts)
+}
+
+// NewT returns a Logger that records entries and also writes them, in logfmt, with t.Log so that
+// they are shown alongside the output of a failed or verbose test.  Entries logged after the test
+// has finished are recorded but not written.
+func NewT(t testing.TB, opts ...log.Option) *Logger {
+	w := &testWriter{t: t}
+	t.Cleanup(w.stop)
+	return newLogger(&recorder{enc: log.LogfmtEncoder{}}, w, opts)
+}
+
+func newLogger(rec *recorder, w io.Writer, opts []log.Option) *Logger {
+	opts = append(opts[:len(opts):len(opts)], log.Output(w), log.Format(rec))
+	return &Logger{LevelLogger: log.New(opts...), rec: rec}
+}
+
+// EnabledFor implements log.NamedLevelLogger, so that levels per name apply to Named loggers wrapping l.
+func (l *Logger) EnabledFor(name string, level log.Level) bool {
+	return l.LevelLogger.(log.NamedLevelLogger).EnabledFor(name, level)
+}
+
+// LogFor implements log.NamedLevelLogger.
+func (l *Logger) LogFor(name string, level log.Level, msg string, fields ...any) error {
+	return l.LevelLogger.(log.NamedLevelLogger).LogFor(name, level, msg, fields...)
+}
+
+// Entries returns the recorded entries that match every filter, in the order they were logged.
+func (l *Logger) Entries(filters ...Filter) []Entry {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+	var entries []Entry
+outer:
+	for _, e := range l.rec.entries {
+		for _, f := range filters {
+			if !f(e) {
+				continue outer
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Reset discards the recorded entries.
+func (l *Logger) Reset() {
+	l.rec.mu.Lock()
+	l.rec.entries = nil
+	l.rec.mu.Unlock()
+}
+
+// AssertLogged fails t unless an entry was logged with msg and every key/value pair in fields.
+// Values are compared with reflect.DeepEqual, except that an expected error also matches any
+// recorded error for which errors.Is reports true, or that has the same message.
+func (l *Logger) AssertLogged(t testing.TB, msg string, fields ...any) {
+	t.Helper()
+	filters := []Filter{WithMessage(msg)}
+	for i := 0; i < len(fields); i += 2 {
+		key, ok := fields[i].(string)
+		if !ok || i+1 == len(fields) {
+			t.Fatalf("logtest: fields must be key/value pairs, got %v", fields)
+		}
+		filters = append(filters, WithField(key, fields[i+1]))
+	}
+	if len(l.Entries(filters...)) == 0 {
+		t.Errorf("expected an entry %q with fields %v, got:%s", msg, fields, l.dump())
+	}
+}
+
+// AssertNotLogged fails t if an entry was logged with msg.
+func (l *Logger) AssertNotLogged(t testing.TB, msg string) {
+	t.Helper()
+	if len(l.Entries(WithMessage(msg))) > 0 {
+		t.Errorf("expected no entry %q, got:%s", msg, l.dump())
+	}
+}
+
+func (l *Logger) dump() string {
+	entries := l.Entries()
+	if len(entries) == 0 {
+		return " no entries"
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString("\n\t")
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
+
+func equal(want, got any) bool {
+	if reflect.DeepEqual(want, got) {
+		return true
+	}
+	wantErr, ok := want.(error)
+	if !ok {
+		return false
+	}
+	gotErr, ok := got.(error)
+	return ok && (errors.Is(gotErr, wantErr) || gotErr.Error() == wantErr.Error())
+}
+
+// recorder is the log.Encoder of a Logger.  It copies each entry, as the logger reuses them, and
+// passes it on to enc if one is set.
+type recorder struct {
+	enc     log.Encoder
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Encode implements log.Encoder.
+func (r *recorder) Encode(buf []byte, e *log.Entry) []byte {
+	entry := Entry{
+		Time:    e.Time,
+		Level:   e.Level,
+		Message: e.Message,
+		Fields:  append([]log.Field(nil), e.Fields...),
+	}
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+	if r.enc == nil {
+		return buf
+	}
+	return r.enc.Encode(buf, e)
+}
+
+// testWriter writes each entry with t.Log until the test has finished.
+type testWriter struct {
+	t    testing.TB
+	mu   sync.Mutex
+	done bool
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.done {
+		w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (w *testWriter) stop() {
+	w.mu.Lock()
+	w.done = true
+	w.mu.Unlock()
+}
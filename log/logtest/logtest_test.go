@@ -0,0 +1,45 @@
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/goaferlx/go-core/log"
+)
+
+func TestLogger(t *testing.T) {
+	l := New(log.MinLevel(log.LevelDebug), log.BaseFields("service", "api"))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			log.Debug(l, "working", "n", i)
+		}(i)
+	}
+	wg.Wait()
+	errTimeout := errors.New("timeout")
+	log.Error(log.WithFields(l, "user", 7), "failed", "error", fmt.Errorf("saving: %w", errTimeout))
+
+	if got := len(l.Entries(AtLevel(log.LevelDebug))); got != 10 {
+		t.Errorf("expected 10 debug entries, got %d", got)
+	}
+	if got := len(l.Entries(WithMessage("working"), WithField("n", 3))); got != 1 {
+		t.Errorf("expected 1 entry with n=3, got %d", got)
+	}
+	l.AssertLogged(t, "failed", "service", "api", "user", 7, "error", errTimeout)
+	l.AssertNotLogged(t, "panic recovered")
+
+	l.Reset()
+	if got := l.Entries(); len(got) != 0 {
+		t.Errorf("expected no entries after Reset, got %v", got)
+	}
+}
+
+func TestNewT(t *testing.T) {
+	l := NewT(t)
+	l.Log("shown with t.Log", "k", "v")
+	l.AssertLogged(t, "shown with t.Log", "k", "v")
+}
@@ -0,0 +1,42 @@
+package log
+
+import "errors"
+
+// Multi returns a Logger that writes every entry to each of loggers, e.g. a console logger at
+// LevelDebug, a JSON file at LevelInfo and a remote sink for errors only, each created by New with
+// its own options.  An entry is only passed to the loggers enabled for its level.  A failing logger
+// does not stop the others being written to, all errors are returned joined.
+func Multi(loggers ...Logger) Logger {
+	return multiLogger(append([]Logger(nil), loggers...))
+}
+
+type multiLogger []Logger
+
+// Log logs msg at LevelInfo to every logger.
+func (m multiLogger) Log(msg string, fields ...interface{}) error {
+	return m.LogLevel(LevelInfo, msg, fields...)
+}
+
+// LogLevel logs msg at level to every logger enabled for level.
+func (m multiLogger) LogLevel(level Level, msg string, fields ...interface{}) error {
+	var errs []error
+	for _, l := range m {
+		if !Enabled(l, level) {
+			continue
+		}
+		if err := LogLevel(l, level, msg, fields...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Enabled reports whether any logger is enabled for level.
+func (m multiLogger) Enabled(level Level) bool {
+	for _, l := range m {
+		if Enabled(l, level) {
+			return true
+		}
+	}
+	return false
+}
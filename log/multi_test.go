@@ -0,0 +1,36 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{ err error }
+
+func (f failingWriter) Write([]byte) (int, error) { return 0, f.err }
+
+func TestMulti(t *testing.T) {
+	var console, file bytes.Buffer
+	errRemote := errors.New("remote down")
+	l := Multi(
+		New(Output(&console), Format(ConsoleEncoder{}), MinLevel(LevelDebug)),
+		New(Output(&file), MinLevel(LevelInfo)),
+		New(Output(failingWriter{errRemote}), MinLevel(LevelError)),
+	)
+
+	if err := Debug(l, "debugging"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	err := Error(l, "failed")
+	if !errors.Is(err, errRemote) {
+		t.Errorf("expected remote error, got %v", err)
+	}
+	if got := strings.Count(console.String(), "\n"); got != 2 {
+		t.Errorf("expected 2 console entries, got %d", got)
+	}
+	if got := file.String(); strings.Contains(got, "debugging") || !strings.Contains(got, "failed") {
+		t.Errorf("expected only the error entry in the file, got %s", got)
+	}
+}
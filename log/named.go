@@ -0,0 +1,98 @@
+package log
+
+// NameKey is the key of the field holding the name of a logger created with Named.
+const NameKey = "logger"
+
+// Named wraps l and adds its name to every entry under NameKey.  Naming a Named logger joins the names
+// with a dot, so Named(Named(l, "db"), "migrate") logs as "db.migrate", as does
+// Named(WithFields(Named(l, "db"), "k", "v"), "migrate").
+//
+// If the logger at the bottom was created by New with a *Levels passed to MinLevel, the level of each
+// entry is checked against the level configured for the longest matching prefix of the name, see
+// Levels.For, rather than the root level.  This holds through WithFields as well.
+func Named(l Logger, name string) Logger {
+	if n, ok := l.(namedLogger); ok {
+		return n.join(name)
+	}
+	return namedLogger{Logger: l, name: name}
+}
+
+// NamedLevelLogger is implemented by loggers that can apply a level per name, such as those created by
+// New with a *Levels passed to MinLevel.  Wrappers of such loggers can implement it to pass the name of
+// a Named logger through.
+type NamedLevelLogger interface {
+	EnabledFor(name string, level Level) bool
+	LogFor(name string, level Level, msg string, fields ...any) error
+}
+
+type namedLogger struct {
+	Logger
+	name string
+}
+
+// Log logs msg at LevelInfo with the name of the logger.  Loggers that do not understand levels
+// are passed the name as a field, without a level.
+func (n namedLogger) Log(msg string, fields ...interface{}) error {
+	if nl, ok := n.Logger.(NamedLevelLogger); ok {
+		return nl.LogFor(n.name, LevelInfo, msg, fields...)
+	}
+	return n.Logger.Log(msg, n.withName(fields)...)
+}
+
+// LogLevel logs msg at level with the name of the logger.
+func (n namedLogger) LogLevel(level Level, msg string, fields ...interface{}) error {
+	if nl, ok := n.Logger.(NamedLevelLogger); ok {
+		return nl.LogFor(n.name, level, msg, fields...)
+	}
+	return LogLevel(n.Logger, level, msg, n.withName(fields)...)
+}
+
+// Enabled reports whether entries at level will be logged under the name of the logger.
+func (n namedLogger) Enabled(level Level) bool {
+	if nl, ok := n.Logger.(NamedLevelLogger); ok {
+		return nl.EnabledFor(n.name, level)
+	}
+	return Enabled(n.Logger, level)
+}
+
+// EnabledFor reports whether entries at level will be logged under name joined to the name of the
+// logger, so that a Named logger wrapped by WithFields and named again keeps its own name.
+func (n namedLogger) EnabledFor(name string, level Level) bool {
+	return n.join(name).Enabled(level)
+}
+
+// LogFor logs msg at level under name joined to the name of the logger.
+func (n namedLogger) LogFor(name string, level Level, msg string, fields ...any) error {
+	return n.join(name).LogLevel(level, msg, fields...)
+}
+
+// join returns the logger named name within n.
+func (n namedLogger) join(name string) namedLogger {
+	switch {
+	case name == "":
+		name = n.name
+	case n.name != "":
+		name = n.name + "." + name
+	}
+	return namedLogger{Logger: n.Logger, name: name}
+}
+
+func (n namedLogger) withName(fields []any) []any {
+	return append([]any{NameKey, n.name}, normalizeFields(fields)...)
+}
+
+// EnabledFor reports whether entries at level will be written for the named logger name.
+func (l *logger) EnabledFor(name string, level Level) bool {
+	if ls, ok := l.level.(*Levels); ok {
+		return level >= ls.For(name)
+	}
+	return l.Enabled(level)
+}
+
+// LogFor writes an entry for the named logger name, if its level is enabled.
+func (l *logger) LogFor(name string, level Level, msg string, fields ...any) error {
+	if !l.EnabledFor(name, level) {
+		return nil
+	}
+	return l.write(level, msg, name, fields)
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels(LevelInfo)
+	levels.Var("db").Set(LevelDebug)
+	logger := New(Output(&buf), Format(LogfmtEncoder{}), MinLevel(levels), BaseFields("service", "api"))
+
+	migrate := Named(Named(logger, "db"), "migrate")
+	access := Named(logger, "http")
+	Debug(migrate, "applying", "version", 3)
+	Debug(access, "hidden")
+	Debug(logger, "hidden")
+	Debug(WithFields(migrate, "k", "v"), "wrapped")
+	Debug(Named(WithFields(Named(logger, "db"), "req", 1), "migrate"), "renamed")
+	Info(access, "request")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"level=debug msg=applying service=api logger=db.migrate version=3",
+		"level=debug msg=wrapped service=api logger=db.migrate k=v",
+		"level=debug msg=renamed service=api logger=db.migrate req=1",
+		"level=info msg=request service=api logger=http",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d entries, got %q", len(want), lines)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Errorf("expected entry ending %q, got %q", want[i], line)
+		}
+	}
+
+	levels.Var("db.migrate").Set(LevelWarn)
+	if migrate.(LevelLogger).Enabled(LevelInfo) || !Enabled(Named(logger, "db.pool"), LevelDebug) {
+		t.Errorf("expected the longest prefix to set the level")
+	}
+	if Enabled(Named(WithFields(Named(logger, "db"), "req", 1), "migrate"), LevelInfo) {
+		t.Errorf("expected the joined name to set the level through WithFields")
+	}
+
+	buf.Reset()
+	Named(mapLogger{&buf}, "plain").Log("no levels")
+	if !strings.Contains(buf.String(), `"logger":"plain"`) {
+		t.Errorf("expected the name as a field of a plain Logger, got %s", buf.String())
+	}
+}
+
+type mapLogger struct{ w io.Writer }
+
+func (m mapLogger) Log(msg string, fields ...any) error { return mapLog(m.w, msg, fields...) }
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures a logger created by New.
+type Option func(*logger)
+
+// Output sets the destination for log output.  Defaults to StdOut.
+func Output(w io.Writer) Option {
+	return func(l *logger) {
+		l.writer = w
+	}
+}
+
+// MinLevel sets the minimum level a logger will write, entries below it are discarded.
+// Defaults to LevelInfo.  Passing a *LevelVar allows the level to be changed whilst the logger is in use.
+func MinLevel(level Leveler) Option {
+	return func(l *logger) {
+		if level == nil {
+			level = LevelInfo
+		}
+		l.level = level
+	}
+}
+
+// Format sets the Encoder used to format entries, e.g. JSONEncoder, LogfmtEncoder or ConsoleEncoder.
+// Without it, a colorized ConsoleEncoder is used when the output is a terminal and JSONEncoder otherwise.
+func Format(enc Encoder) Option {
+	return func(l *logger) {
+		l.encoder = enc
+		l.auto = false
+	}
+}
+
+// TimeFormat sets the layout, as used by time.Format, of the entry timestamps written by the built in
+// encoders.  Encoders that set their own TimeFormat keep it.
+func TimeFormat(layout string) Option {
+	return func(l *logger) {
+		l.config.TimeFormat = layout
+	}
+}
+
+// TimeZone sets the location entry timestamps are written in.  Defaults to UTC.
+func TimeZone(loc *time.Location) Option {
+	return func(l *logger) {
+		if loc == nil {
+			loc = time.UTC
+		}
+		l.loc = loc
+	}
+}
+
+// FieldNames overrides the keys the built in encoders write the timestamp, message and level under,
+// e.g. "message" and "severity" to follow GCP conventions.  Empty names are left as the encoder default,
+// as are names the encoder sets itself.
+func FieldNames(timeKey, messageKey, levelKey string) Option {
+	return func(l *logger) {
+		l.config.TimeKey = timeKey
+		l.config.MessageKey = messageKey
+		l.config.LevelKey = levelKey
+	}
+}
+
+// BaseFields sets key/value pairs written on every entry before the fields passed to Log,
+// e.g. service, version and hostname.
+func BaseFields(fields ...any) Option {
+	return func(l *logger) {
+		l.fields = appendFields(l.fields, fields)
+	}
+}
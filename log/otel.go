@@ -0,0 +1,220 @@
+package log
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of the fields holding the W3C trace context of an entry, see ParseTraceparent.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceparentHeader is the W3C Trace Context header carrying the trace and span of a request.
+const TraceparentHeader = "traceparent"
+
+// TraceContext is the trace and parent span of a request, as lower case hex.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Flags   byte
+}
+
+// ParseTraceparent parses the value of a W3C traceparent header, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".  It reports false if the value is
+// malformed or the trace or span ID is all zeros, in which case the header must be ignored.
+// Versions after 00 are accepted as long as they start with the fields of version 00.
+func ParseTraceparent(s string) (TraceContext, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return TraceContext{}, false
+	}
+	version, traceID, spanID, flags := s[:2], s[3:35], s[36:52], s[53:55]
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return TraceContext{}, false
+	}
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return TraceContext{}, false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return TraceContext{}, false
+	}
+	f, _ := strconv.ParseUint(flags, 16, 8)
+	return TraceContext{TraceID: traceID, SpanID: spanID, Flags: byte(f)}, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// OTelEncoder writes each entry as a single line JSON object following the OpenTelemetry log data
+// model, in the form of an OTLP/JSON LogRecord.  The message is the body, and the fields are attributes
+// except TraceIDKey and SpanIDKey, which set the trace and span IDs of the record.  Use it with a writer
+// from NewOTLPWriter to export entries to a collector, or with any other writer for collectors that
+// tail files.
+type OTelEncoder struct{}
+
+// otelSeverity maps a level to an OpenTelemetry severity number.  The levels follow log/slog, which
+// are spaced to match the OpenTelemetry ranges: debug is 5, info 9, warn 13 and error 17.
+func otelSeverity(level Level) int {
+	n := int(level) + 9
+	if n < 1 {
+		return 1
+	}
+	if n > 24 {
+		return 24
+	}
+	return n
+}
+
+// Encode implements Encoder.
+func (OTelEncoder) Encode(buf []byte, e *Entry) []byte {
+	buf = append(buf, `{"timeUnixNano":"`...)
+	buf = strconv.AppendInt(buf, e.Time.UnixNano(), 10)
+	buf = append(buf, `","observedTimeUnixNano":"`...)
+	buf = strconv.AppendInt(buf, time.Now().UnixNano(), 10)
+	buf = append(buf, `","severityNumber":`...)
+	buf = strconv.AppendInt(buf, int64(otelSeverity(e.Level)), 10)
+	buf = append(buf, `,"severityText":`...)
+	buf = appendJSONString(buf, strings.ToUpper(e.Level.String()))
+	buf = append(buf, `,"body":{"stringValue":`...)
+	buf = appendJSONString(buf, e.Message)
+	buf = append(buf, `},"attributes":[`...)
+	var traceID, spanID string
+	n := 0
+	for _, f := range e.Fields {
+		if s, ok := resolve(f.Value).(string); ok {
+			switch {
+			case f.Key == TraceIDKey && len(s) == 32 && isLowerHex(s):
+				traceID = s
+				continue
+			case f.Key == SpanIDKey && len(s) == 16 && isLowerHex(s):
+				spanID = s
+				continue
+			}
+		}
+		if n > 0 {
+			buf = append(buf, ',')
+		}
+		n++
+		buf = append(buf, `{"key":`...)
+		buf = appendJSONString(buf, f.Key)
+		buf = append(buf, `,"value":`...)
+		buf = appendOTelValue(buf, f.Value)
+		buf = append(buf, '}')
+	}
+	buf = append(buf, ']')
+	if traceID != "" {
+		buf = append(buf, `,"traceId":"`...)
+		buf = append(buf, traceID...)
+		buf = append(buf, '"')
+	}
+	if spanID != "" {
+		buf = append(buf, `,"spanId":"`...)
+		buf = append(buf, spanID...)
+		buf = append(buf, '"')
+	}
+	return append(buf, '}', '\n')
+}
+
+// appendOTelValue appends v as an OTLP/JSON AnyValue.  Integers are written as strings, following
+// the JSON mapping of int64, unsigned integers too large for an int64 as string values, and values
+// without an OpenTelemetry type as their logfmt text.
+func appendOTelValue(buf []byte, v any) []byte {
+	switch v := resolve(v).(type) {
+	case string:
+		buf = append(buf, `{"stringValue":`...)
+		buf = appendJSONString(buf, v)
+	case bool:
+		buf = append(buf, `{"boolValue":`...)
+		buf = strconv.AppendBool(buf, v)
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		buf = append(buf, `{"intValue":"`...)
+		buf = appendJSONValue(buf, v)
+		buf = append(buf, '"')
+	case uint:
+		buf = appendOTelUint(buf, uint64(v))
+	case uint64:
+		buf = appendOTelUint(buf, v)
+	case float32:
+		buf = append(buf, `{"doubleValue":`...)
+		buf = appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		buf = append(buf, `{"doubleValue":`...)
+		buf = appendJSONFloat(buf, v, 64)
+	default:
+		text := string(appendTextValue(nil, v))
+		if unquoted, err := strconv.Unquote(text); err == nil {
+			text = unquoted
+		}
+		buf = append(buf, `{"stringValue":`...)
+		buf = appendJSONString(buf, text)
+	}
+	return append(buf, '}')
+}
+
+// appendOTelUint appends the opening of an AnyValue holding u, as an intValue if it fits in an int64.
+func appendOTelUint(buf []byte, u uint64) []byte {
+	if u > math.MaxInt64 {
+		buf = append(buf, `{"stringValue":"`...)
+	} else {
+		buf = append(buf, `{"intValue":"`...)
+	}
+	buf = strconv.AppendUint(buf, u, 10)
+	return append(buf, '"')
+}
+
+// OTLPLogsPath is the path of the OTLP/HTTP logs endpoint on a collector.
+const OTLPLogsPath = "/v1/logs"
+
+// otelScope is the instrumentation scope records are exported under.
+const otelScope = "github.com/goaferlx/go-core/log"
+
+// NewOTLPWriter returns a Shipper exporting batches of records to an OpenTelemetry collector with
+// OTLP/HTTP and JSON encoding.  endpoint is the base URL of the collector, e.g. "http://localhost:4318",
+// OTLPLogsPath is appended to it.  resource holds attributes describing the source of the logs, such
+// as "service.name", and header any headers required by the collector.  Each record must be an OTLP
+// LogRecord, so it should be used with an OTelEncoder:
+//
+//	w := log.NewOTLPWriter("http://localhost:4318", map[string]string{"service.name": "api"}, nil, nil, log.ShipConfig{})
+//	logger := log.New(log.Output(w), log.Format(log.OTelEncoder{}))
+//
+// Retries and spilling behave as for NewHTTPWriter.
+func NewOTLPWriter(endpoint string, resource map[string]string, header http.Header, client *http.Client, cfg ShipConfig) *Shipper {
+	keys := make([]string, 0, len(resource))
+	for k := range resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var attrs []byte
+	for i, k := range keys {
+		if i > 0 {
+			attrs = append(attrs, ',')
+		}
+		attrs = append(attrs, `{"key":`...)
+		attrs = appendJSONString(attrs, k)
+		attrs = append(attrs, `,"value":`...)
+		attrs = appendOTelValue(attrs, resource[k])
+		attrs = append(attrs, '}')
+	}
+
+	url := strings.TrimRight(endpoint, "/") + OTLPLogsPath
+	return newShipper(newHTTPTransport(url, header, client, func(buf *bytes.Buffer, records [][]byte) {
+		buf.WriteString(`{"resourceLogs":[{"resource":{"attributes":[`)
+		buf.Write(attrs)
+		buf.WriteString(`]},"scopeLogs":[{"scope":{"name":"` + otelScope + `"},"logRecords":`)
+		writeJSONArray(buf, records)
+		buf.WriteString(`}]}]}`)
+	}), cfg)
+}
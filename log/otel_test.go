@@ -0,0 +1,137 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTLPWriter(t *testing.T) {
+	type anyValue map[string]any
+	type record struct {
+		TimeUnixNano   string
+		SeverityNumber int
+		SeverityText   string
+		Body           anyValue
+		Attributes     []struct {
+			Key   string
+			Value anyValue
+		}
+		TraceID string
+		SpanID  string
+	}
+	type export struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value anyValue
+				}
+			}
+			ScopeLogs []struct {
+				LogRecords []record
+			}
+		}
+	}
+	var mu sync.Mutex
+	var requests []export
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != OTLPLogsPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req export
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	w := NewOTLPWriter(collector.URL+"/", map[string]string{"service.name": "api"}, nil, nil, ShipConfig{})
+	logger := New(Output(w), Format(OTelEncoder{}))
+	tc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok {
+		t.Fatal("expected a valid traceparent")
+	}
+	ctx := ContextWithFields(NewContext(context.Background(), logger), TraceIDKey, tc.TraceID, SpanIDKey, tc.SpanID)
+	Warn(FromContext(ctx), "slow", "took", 2*time.Second, "rows", 3, "cached", false, "bytes", uint64(42), "id", uint64(math.MaxUint64))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 1 || len(requests[0].ResourceLogs) != 1 {
+		t.Fatalf("expected a single export, got %+v", requests)
+	}
+	rl := requests[0].ResourceLogs[0]
+	if attrs := rl.Resource.Attributes; len(attrs) != 1 || attrs[0].Key != "service.name" || attrs[0].Value["stringValue"] != "api" {
+		t.Errorf("expected the service name resource, got %+v", attrs)
+	}
+	rec := rl.ScopeLogs[0].LogRecords[0]
+	if rec.SeverityNumber != 13 || rec.SeverityText != "WARN" || rec.Body["stringValue"] != "slow" || rec.TimeUnixNano == "" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if rec.TraceID != tc.TraceID || rec.SpanID != tc.SpanID {
+		t.Errorf("expected trace %s and span %s, got %s and %s", tc.TraceID, tc.SpanID, rec.TraceID, rec.SpanID)
+	}
+	attrs := map[string]anyValue{}
+	for _, a := range rec.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	want := map[string]anyValue{
+		"took":   {"stringValue": "2s"},
+		"rows":   {"intValue": "3"},
+		"cached": {"boolValue": false},
+		"bytes":  {"intValue": "42"},
+		"id":     {"stringValue": "18446744073709551615"},
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("expected attributes %v, got %v", want, attrs)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  TraceContext
+		ok    bool
+	}{
+		{
+			name:  "valid",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:  TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Flags: 1},
+			ok:    true,
+		},
+		{
+			name:  "later version with extra fields",
+			value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+			want:  TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"},
+			ok:    true,
+		},
+		{name: "empty", value: ""},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "upper case", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "version 00 with extra fields", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "bad separator", value: "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTraceparent(tt.value)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseTraceparent(%q) = %+v, %t, expected %+v, %t", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces values removed by redaction.
+const Redacted = "[REDACTED]"
+
+// Redactor is implemented by types holding sensitive data.  Redact returns the value to log in their
+// place, which should not itself be a Redactor.  It is always honoured, with or without the Redact option.
+type Redactor interface {
+	Redact() any
+}
+
+// Redaction configures the removal of sensitive data from log entries by the Redact option.
+type Redaction struct {
+	// Keys lists field keys whose values are replaced with Redacted.  A key matches if it contains
+	// any of them, ignoring case, so "password" also matches "db_password".
+	Keys []string
+	// Patterns are matched against the message and string or error field values, and each match is
+	// replaced with Redacted.
+	Patterns []*regexp.Regexp
+}
+
+// DefaultRedaction removes common credentials by key, as well as bearer tokens and card numbers
+// wherever they appear in a message or string value.
+var DefaultRedaction = Redaction{
+	Keys: []string{"password", "passwd", "secret", "token", "authorization", "api_key", "apikey", "cookie"},
+	Patterns: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-._~+/]+=*`),
+		regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+	},
+}
+
+// Redact sets the Redaction applied to every entry before it is encoded, including base fields.
+// Values are only inspected once they have been resolved, so LogValuers are covered too.
+func Redact(r Redaction) Option {
+	return func(l *logger) {
+		keys := make([]string, len(r.Keys))
+		for i, k := range r.Keys {
+			keys[i] = strings.ToLower(k)
+		}
+		l.redaction = &Redaction{Keys: keys, Patterns: r.Patterns}
+	}
+}
+
+// apply redacts the message and fields of e in place.  Keys must already be lower case.
+func (r *Redaction) apply(e *Entry) {
+	e.Message = r.scrub(e.Message)
+	for i, f := range e.Fields {
+		if r.matchKey(f.Key) {
+			e.Fields[i].Value = Redacted
+			continue
+		}
+		if len(r.Patterns) == 0 {
+			continue
+		}
+		switch v := resolve(f.Value).(type) {
+		case string:
+			if s := r.scrub(v); s != v {
+				e.Fields[i].Value = s
+			}
+		case error:
+			if msg := v.Error(); r.scrub(msg) != msg {
+				e.Fields[i].Value = r.scrub(msg)
+			}
+		}
+	}
+}
+
+func (r *Redaction) matchKey(key string) bool {
+	if len(r.Keys) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, k := range r.Keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redaction) scrub(s string) string {
+	for _, p := range r.Patterns {
+		s = p.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type credentials struct {
+	User     string
+	Password string
+}
+
+func (c credentials) Redact() any {
+	return struct{ User string }{c.User}
+}
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(Output(&buf), Redact(DefaultRedaction), BaseFields("api_token", "base-secret"))
+	logger.Log("calling with Bearer abc.def-123",
+		"DB_Password", "hunter2",
+		"header", "Authorization: Bearer abc.def-123",
+		"card", "4111 1111 1111 1111",
+		"creds", credentials{"gopher", "hunter2"},
+		"user", "gopher",
+	)
+	got := buf.String()
+	for _, secret := range []string{"hunter2", "abc.def-123", "4111", "base-secret"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"user":"gopher"`) || !strings.Contains(got, `"creds":{"User":"gopher"}`) {
+		t.Errorf("expected other fields to be kept, got %s", got)
+	}
+
+	buf.Reset()
+	New(Output(&buf)).Log("no option", "creds", credentials{"gopher", "hunter2"})
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("expected Redactor to be honoured without the option, got %s", buf.String())
+	}
+}
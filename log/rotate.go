@@ -0,0 +1,321 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is appended to the filename of rotated files.  It sorts in time order and
+// avoids characters that are invalid in Windows filenames.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig controls when a RotatingFile is rotated and what happens to the old files.
+// Zero values disable the corresponding behaviour.
+type RotateConfig struct {
+	MaxSize    int64         // size in bytes the file may grow to before it is rotated.
+	MaxAge     time.Duration // time a file is written to before it is rotated.
+	MaxBackups int           // number of rotated files to keep, the oldest are removed first.
+	Compress   bool          // gzip rotated files.
+}
+
+// RotatingFile is an io.Writer that writes to a file, rotating it by size and age.  Rotated files are
+// renamed with a timestamp suffix, e.g. "app.log.2023-01-28T12-00-00.000", followed by a counter such
+// as "-1" if a backup with that timestamp already exists, and optionally compressed.  Only files
+// named this way are counted as backups, other files in the directory are left alone.
+// It is safe for concurrent use, and each call to Write goes to a single file, so it can be used as
+// the output of a logger.
+type RotatingFile struct {
+	filename string
+	cfg      RotateConfig
+
+	mu       sync.Mutex
+	file     *os.File // nil if the file could not be opened, it is opened again by the next Write.
+	closed   bool
+	size     int64
+	openedAt time.Time
+
+	millMu sync.Mutex     // serialises compressing and removing backups.
+	wg     sync.WaitGroup // tracks background compression, waited for by Close.
+}
+
+// NewRotatingFile opens, or creates, filename for appending and returns a RotatingFile writing to it.
+func NewRotatingFile(filename string, cfg RotateConfig) (*RotatingFile, error) {
+	f := &RotatingFile{filename: filename, cfg: cfg}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first if p would take it over MaxSize or it is older than MaxAge.
+// If the file could not be opened by an earlier rotation or reopen, opening it is tried again.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it with a timestamp suffix and starts a new file.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file by name, for use after it has been moved by an external tool such as logrotate.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// ReopenOnSignal reopens the file whenever the process receives SIGHUP, until stop is called.
+func (f *RotatingFile) ReopenOnSignal() (stop func()) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-sig:
+				// there is nowhere to report the error, the next Write will fail if the file is unusable.
+				_ = f.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sig)
+			close(done)
+		})
+	}
+}
+
+// Close closes the file and waits for any background compression to finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return ErrClosed
+	}
+	f.closed = true
+	err := f.closeFile()
+	f.mu.Unlock()
+	f.wg.Wait()
+	return err
+}
+
+// closeFile closes the current file, if it is open, it must be called with f.mu held.
+func (f *RotatingFile) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("log: closing %s: %w", f.filename, err)
+	}
+	return nil
+}
+
+// due reports whether the file must be rotated before writing n bytes, it must be called with f.mu held.
+func (f *RotatingFile) due(n int64) bool {
+	if f.cfg.MaxSize > 0 && f.size > 0 && f.size+n > f.cfg.MaxSize {
+		return true
+	}
+	return f.cfg.MaxAge > 0 && time.Since(f.openedAt) >= f.cfg.MaxAge
+}
+
+// open must be called with f.mu held.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0o755); err != nil {
+		return fmt.Errorf("log: creating log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("log: opening %s: %w", f.filename, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("log: opening %s: %w", f.filename, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	if f.size > 0 {
+		f.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// rotate must be called with f.mu held.
+func (f *RotatingFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.filename, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("log: rotating %s: %w", f.filename, err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.mill(backup)
+	}()
+	return nil
+}
+
+// mill compresses the new backup, if required, and removes backups beyond MaxBackups.
+// Errors are ignored as they must not stop logging, the files are left for the next rotation.
+func (f *RotatingFile) mill(backup string) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+	if f.cfg.Compress {
+		_ = compressFile(backup)
+	}
+	if f.cfg.MaxBackups <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(filepath.Dir(f.filename))
+	if err != nil {
+		return
+	}
+	type backupFile struct {
+		name    string
+		t       time.Time
+		counter int
+	}
+	var backups []backupFile
+	for _, e := range entries {
+		if t, counter, ok := f.parseBackup(e.Name()); ok && e.Type().IsRegular() {
+			backups = append(backups, backupFile{filepath.Join(filepath.Dir(f.filename), e.Name()), t, counter})
+		}
+	}
+	// oldest first.
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].t.Equal(backups[j].t) {
+			return backups[i].t.Before(backups[j].t)
+		}
+		return backups[i].counter < backups[j].counter
+	})
+	for len(backups) > f.cfg.MaxBackups {
+		_ = os.Remove(backups[0].name)
+		backups = backups[1:]
+	}
+}
+
+// backupName returns a name for a backup rotated at t that is not taken by an existing backup,
+// compressed or not.
+func (f *RotatingFile) backupName(t time.Time) string {
+	base := f.filename + "." + t.Format(backupTimeFormat)
+	name := base
+	for counter := 1; exists(name) || exists(name+".gz"); counter++ {
+		name = base + "-" + strconv.Itoa(counter)
+	}
+	return name
+}
+
+// parseBackup reports whether the file in the same directory named name is a backup of the file,
+// and if so the time it was rotated at and its counter, which is 0 for the first backup at that time.
+func (f *RotatingFile) parseBackup(name string) (t time.Time, counter int, ok bool) {
+	suffix, ok := strings.CutPrefix(name, filepath.Base(f.filename)+".")
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	suffix = strings.TrimSuffix(suffix, ".gz")
+	if len(suffix) < len(backupTimeFormat) {
+		return time.Time{}, 0, false
+	}
+	t, err := time.ParseInLocation(backupTimeFormat, suffix[:len(backupTimeFormat)], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	if rest := suffix[len(backupTimeFormat):]; rest != "" {
+		digits, ok := strings.CutPrefix(rest, "-")
+		if !ok || digits == "" || strings.Trim(digits, "0123456789") != "" {
+			return time.Time{}, 0, false
+		}
+		if counter, err = strconv.Atoi(digits); err != nil || counter < 1 {
+			return time.Time{}, 0, false
+		}
+	}
+	return t, counter, true
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// compressFile gzips name to name.gz and removes the original.
+func compressFile(name string) error {
+	if strings.HasSuffix(name, ".gz") {
+		return nil
+	}
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
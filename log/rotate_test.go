@@ -0,0 +1,131 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(name, RotateConfig{MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := f.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := filepath.Glob(name + ".*.gz")
+	if len(backups) != 2 {
+		t.Errorf("expected 2 compressed backups, got %v", backups)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "0123456789" {
+		t.Errorf("expected current file to hold the last write, got %q", b)
+	}
+
+	t.Run("reopens a moved file", func(t *testing.T) {
+		f, err := NewRotatingFile(name, RotateConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := os.Rename(name, name+".moved"); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Reopen(); err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("fresh"))
+		if b, _ := os.ReadFile(name); string(b) != "fresh" {
+			t.Errorf("expected reopened file to hold %q, got %q", "fresh", b)
+		}
+	})
+
+	t.Run("keeps backups rotated in the same millisecond", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "app.log")
+		unrelated := []string{name + ".moved", name + ".2023-01-28T12-00-00.000-x", name + ".2023-01-28T12-00-00.000-0"}
+		for _, u := range unrelated {
+			if err := os.WriteFile(u, []byte("keep"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		f, err := NewRotatingFile(name, RotateConfig{MaxBackups: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			f.Write([]byte(strconv.Itoa(i)))
+			if err := f.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		backups, _ := filepath.Glob(name + ".2*")
+		var kept []string
+		for _, b := range backups {
+			content, _ := os.ReadFile(b)
+			if string(content) != "keep" {
+				kept = append(kept, string(content))
+			}
+		}
+		sort.Strings(kept)
+		if want := []string{"2", "3", "4"}; !reflect.DeepEqual(kept, want) {
+			t.Errorf("expected the newest backups %v to be kept, got %v", want, kept)
+		}
+		for _, u := range unrelated {
+			if _, err := os.Stat(u); err != nil {
+				t.Errorf("expected %s to be left alone, got %v", filepath.Base(u), err)
+			}
+		}
+	})
+
+	t.Run("opens again after a failed reopen", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "app.log")
+		f, err := NewRotatingFile(name, RotateConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		// a directory in the way of the file makes opening it fail.
+		if err := os.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Mkdir(name, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Reopen(); err == nil {
+			t.Fatal("expected reopen to fail")
+		}
+		if _, err := f.Write([]byte("lost")); err == nil || errors.Is(err, ErrClosed) {
+			t.Errorf("expected an error opening the file, got %v", err)
+		}
+		if err := os.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte("recovered")); err != nil {
+			t.Fatalf("expected write to open the file again, got %v", err)
+		}
+		if b, _ := os.ReadFile(name); string(b) != "recovered" {
+			t.Errorf("expected file to hold %q, got %q", "recovered", b)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SampleConfig controls how many entries with the same level and message a Sampler lets through.
+// Within each Interval the First entries are logged, then every Thereafter-th entry.  A zero
+// Interval disables sampling, and a zero Thereafter drops everything after the First entries.
+type SampleConfig struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+}
+
+// SampleStats counts the entries seen by a Sampler.
+type SampleStats struct {
+	Logged  uint64 // entries passed to the underlying logger, excluding summary lines.
+	Dropped uint64 // entries suppressed by sampling.
+}
+
+// Sampler wraps a Logger and limits the rate of repeated entries, such as those from a hot error path.
+// When an interval ends with entries suppressed, a summary line is logged at the same level with the
+// message and the number suppressed.  Summaries are not logged by a timer: they appear with the next
+// entry for the message, or with the first entry for any message once the interval has ended, which
+// also forgets the message, or on Flush.  It is safe for concurrent use.
+type Sampler struct {
+	logger     Logger
+	cfg        SampleConfig
+	perLevel   map[Level]SampleConfig
+	sweepEvery time.Duration // shortest sampling interval, how often expired counters are evicted.
+
+	mu        sync.Mutex
+	counters  map[sampleKey]*sampleCounter
+	lastSweep time.Time
+
+	logged  atomic.Uint64
+	dropped atomic.Uint64
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+// sampleSummary is a summary line waiting to be logged once s.mu is released.
+type sampleSummary struct {
+	key        sampleKey
+	suppressed int
+}
+
+type sampleCounter struct {
+	start      time.Time // start of the current interval.
+	count      int       // entries seen in the current interval.
+	suppressed int       // entries dropped in the current interval.
+}
+
+// NewSampler returns a Sampler writing to l.  cfg applies to every level that is not in perLevel,
+// e.g. to sample debug and info entries whilst always logging errors.
+func NewSampler(l Logger, cfg SampleConfig, perLevel map[Level]SampleConfig) *Sampler {
+	levels := make(map[Level]SampleConfig, len(perLevel))
+	sweepEvery := cfg.Interval
+	for lvl, c := range perLevel {
+		levels[lvl] = c
+		if c.Interval > 0 && (sweepEvery <= 0 || c.Interval < sweepEvery) {
+			sweepEvery = c.Interval
+		}
+	}
+	return &Sampler{
+		logger:     l,
+		cfg:        cfg,
+		perLevel:   levels,
+		sweepEvery: sweepEvery,
+		counters:   make(map[sampleKey]*sampleCounter),
+	}
+}
+
+// Log logs msg at LevelInfo, subject to sampling.
+func (s *Sampler) Log(msg string, fields ...interface{}) error {
+	return s.LogLevel(LevelInfo, msg, fields...)
+}
+
+// LogLevel logs msg at level, subject to sampling.
+func (s *Sampler) LogLevel(level Level, msg string, fields ...interface{}) error {
+	if !s.Enabled(level) {
+		return nil
+	}
+	cfg := s.config(level)
+	if cfg.Interval <= 0 {
+		s.logged.Add(1)
+		return LogLevel(s.logger, level, msg, fields...)
+	}
+
+	now := time.Now()
+	key := sampleKey{level: level, msg: msg}
+	s.mu.Lock()
+	c, ok := s.counters[key]
+	if !ok {
+		c = &sampleCounter{start: now}
+		s.counters[key] = c
+	}
+	var suppressed int
+	if now.Sub(c.start) >= cfg.Interval {
+		suppressed = c.suppressed
+		*c = sampleCounter{start: now}
+	}
+	c.count++
+	allow := c.count <= cfg.First || (cfg.Thereafter > 0 && (c.count-cfg.First)%cfg.Thereafter == 0)
+	if !allow {
+		c.suppressed++
+	}
+	var summaries []sampleSummary
+	if suppressed > 0 {
+		summaries = append(summaries, sampleSummary{key, suppressed})
+	}
+	if now.Sub(s.lastSweep) >= s.sweepEvery {
+		s.lastSweep = now
+		summaries = s.evict(now, key, summaries)
+	}
+	s.mu.Unlock()
+
+	err := s.summaries(summaries)
+	if !allow {
+		s.dropped.Add(1)
+		return err
+	}
+	s.logged.Add(1)
+	if lerr := LogLevel(s.logger, level, msg, fields...); lerr != nil {
+		return lerr
+	}
+	return err
+}
+
+// Enabled reports whether the underlying logger will log entries at level.
+func (s *Sampler) Enabled(level Level) bool {
+	return Enabled(s.logger, level)
+}
+
+// Stats returns the number of entries logged and dropped since the Sampler was created.
+func (s *Sampler) Stats() SampleStats {
+	return SampleStats{Logged: s.logged.Load(), Dropped: s.dropped.Load()}
+}
+
+// Flush logs a summary for every message with suppressed entries and forgets messages whose interval
+// has ended.  It can be called periodically, or on shutdown, so that suppressed entries are reported
+// even if no further entries are logged.
+func (s *Sampler) Flush() error {
+	var summaries []sampleSummary
+	now := time.Now()
+	s.mu.Lock()
+	for key, c := range s.counters {
+		if c.suppressed > 0 {
+			summaries = append(summaries, sampleSummary{key, c.suppressed})
+			c.suppressed = 0
+		}
+		if now.Sub(c.start) >= s.config(key.level).Interval {
+			delete(s.counters, key)
+		}
+	}
+	s.mu.Unlock()
+	return s.summaries(summaries)
+}
+
+// evict forgets the messages other than current whose interval has ended, so that messages that are
+// not logged again do not hold memory, and appends their summaries.  It must be called with s.mu held.
+func (s *Sampler) evict(now time.Time, current sampleKey, summaries []sampleSummary) []sampleSummary {
+	for key, c := range s.counters {
+		if key == current || now.Sub(c.start) < s.config(key.level).Interval {
+			continue
+		}
+		if c.suppressed > 0 {
+			summaries = append(summaries, sampleSummary{key, c.suppressed})
+		}
+		delete(s.counters, key)
+	}
+	return summaries
+}
+
+// summaries logs each summary, returning the first error.
+func (s *Sampler) summaries(summaries []sampleSummary) error {
+	var err error
+	for _, p := range summaries {
+		if serr := s.summary(p.key, p.suppressed); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+func (s *Sampler) summary(key sampleKey, suppressed int) error {
+	return LogLevel(s.logger, key.level, "log entries suppressed by sampling", "sampled_msg", key.msg, "suppressed", suppressed)
+}
+
+func (s *Sampler) config(level Level) SampleConfig {
+	if cfg, ok := s.perLevel[level]; ok {
+		return cfg
+	}
+	return s.cfg
+}
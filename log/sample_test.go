@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSampler(New(Output(&buf), Format(LogfmtEncoder{})),
+		SampleConfig{Interval: time.Hour, First: 2, Thereafter: 3},
+		map[Level]SampleConfig{LevelError: {}},
+	)
+	for i := 0; i < 10; i++ {
+		s.Log("hot path")
+		Error(s, "always logged")
+	}
+	// entries 1, 2, 5 and 8 of the hot path are logged.
+	if got := strings.Count(buf.String(), "msg=\"hot path\""); got != 4 {
+		t.Errorf("expected 4 sampled entries, got %d", got)
+	}
+	if got := strings.Count(buf.String(), "msg=\"always logged\""); got != 10 {
+		t.Errorf("expected 10 error entries, got %d", got)
+	}
+	if got, want := s.Stats(), (SampleStats{Logged: 14, Dropped: 6}); got != want {
+		t.Errorf("expected stats %+v, got %+v", want, got)
+	}
+
+	buf.Reset()
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, `sampled_msg="hot path" suppressed=6`) {
+		t.Errorf("expected summary line, got %q", got)
+	}
+
+	t.Run("evicts expired messages", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewSampler(New(Output(&buf), Format(LogfmtEncoder{})), SampleConfig{Interval: 10 * time.Millisecond, First: 1}, nil)
+		for i := 0; i < 3; i++ {
+			s.Log("once")
+		}
+		time.Sleep(20 * time.Millisecond)
+		s.Log("other")
+		if got := buf.String(); !strings.Contains(got, `sampled_msg=once suppressed=2`) {
+			t.Errorf("expected summary for the expired message, got %q", got)
+		}
+		s.mu.Lock()
+		n := len(s.counters)
+		s.mu.Unlock()
+		if n != 1 {
+			t.Errorf("expected only the current message to be tracked, got %d", n)
+		}
+	})
+}
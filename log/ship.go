@@ -0,0 +1,500 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default values for a ShipConfig.
+const (
+	DefaultShipQueueSize      int           = 4096
+	DefaultShipBatchSize      int           = 100
+	DefaultShipFlushInterval  time.Duration = 1 * time.Second
+	DefaultShipMaxRetries     int           = 3
+	DefaultShipRetryBaseDelay time.Duration = 100 * time.Millisecond
+	DefaultShipRetryMaxDelay  time.Duration = 5 * time.Second
+)
+
+// ShipConfig configures the buffering, retries and spilling of a Shipper.  Zero values are replaced
+// with the package defaults, except SpillPath.
+type ShipConfig struct {
+	QueueSize      int           // records buffered in memory, further writes are dropped.
+	BatchSize      int           // maximum records sent at once.
+	FlushInterval  time.Duration // maximum time a record waits for its batch to fill.
+	MaxRetries     int           // attempts to resend a failed batch before it is spilled.
+	RetryBaseDelay time.Duration // backoff before the first retry, doubled for each retry after.
+	RetryMaxDelay  time.Duration // upper bound of the backoff.
+	// SpillPath is a file batches are written to when the endpoint cannot be reached, to be resent
+	// once a send succeeds again.  If empty, undeliverable batches are dropped.
+	SpillPath string
+}
+
+func (c ShipConfig) withDefaults() ShipConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultShipQueueSize
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultShipBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = DefaultShipFlushInterval
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	} else if c.MaxRetries == 0 {
+		c.MaxRetries = DefaultShipMaxRetries
+	}
+	if c.RetryBaseDelay <= 0 {
+		c.RetryBaseDelay = DefaultShipRetryBaseDelay
+	}
+	if c.RetryMaxDelay <= 0 {
+		c.RetryMaxDelay = DefaultShipRetryMaxDelay
+	}
+	return c
+}
+
+// ShipStats counts the records handled by a Shipper.
+type ShipStats struct {
+	Sent    uint64 // records delivered to the endpoint.
+	Dropped uint64 // records discarded because the queue was full or the endpoint rejected them.
+	Spilled uint64 // records written to the spill file.
+}
+
+// transport delivers encoded records to an endpoint.
+type transport interface {
+	// send delivers records, returning how many of the first records were delivered, and how many
+	// of them were skipped as they can never be, even if it fails, so that only the rest are retried.
+	send(records [][]byte) (sent, skipped int, err error)
+	close() error
+}
+
+// permanentError marks a failure that retrying will not fix, e.g. a rejected payload.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Shipper is an io.Writer that ships each write, as a single record, to a network endpoint on a
+// separate goroutine.  Records are sent in batches, failed batches are retried with backoff and then
+// spilled to disk if a SpillPath is set.  Whilst waiting to retry, new records are held in memory,
+// up to QueueSize, or spilled, so the queue keeps draining.  It is safe for concurrent use and is
+// created by one of NewSyslogWriter, NewGELFWriter or NewHTTPWriter, to be used as the output of a
+// logger with the matching encoder.
+type Shipper struct {
+	cfg       ShipConfig
+	transport transport
+
+	closeMu sync.RWMutex // held for writing whilst closing the queue.
+	closed  bool
+	queue   chan []byte
+	flush   chan chan struct{}
+	done    chan struct{}
+
+	// owned by the run goroutine.
+	pending  [][]byte         // records that failed to send, waiting for retry.
+	attempts int              // consecutive failed attempts to send.
+	retry    <-chan time.Time // fires when the pending records are due to be retried, nil if none are.
+	hasSpill bool             // the spill file may hold records.
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	spilled atomic.Uint64
+}
+
+func newShipper(t transport, cfg ShipConfig) *Shipper {
+	cfg = cfg.withDefaults()
+	s := &Shipper{
+		cfg:       cfg,
+		transport: t,
+		queue:     make(chan []byte, cfg.QueueSize),
+		flush:     make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if cfg.SpillPath != "" {
+		_, err := os.Stat(cfg.SpillPath)
+		s.hasSpill = err == nil
+		if torn, _ := trimSpill(cfg.SpillPath); torn {
+			s.dropped.Add(1)
+		}
+	}
+	go s.run()
+	return s
+}
+
+// Write queues a copy of p as one record.  If the queue is full the record is dropped.
+func (s *Shipper) Write(p []byte) (int, error) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+	select {
+	case s.queue <- append([]byte(nil), p...):
+	default:
+		s.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush sends every queued record, waiting until each has been delivered, spilled or dropped,
+// including any retries.
+func (s *Shipper) Flush() error {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		return ErrClosed
+	}
+	ack := make(chan struct{})
+	s.flush <- ack
+	<-ack
+	return nil
+}
+
+// Close stops accepting writes, ships the queued records and closes the connection.
+func (s *Shipper) Close() error {
+	s.closeMu.Lock()
+	if s.closed {
+		s.closeMu.Unlock()
+		return ErrClosed
+	}
+	s.closed = true
+	close(s.queue)
+	s.closeMu.Unlock()
+
+	<-s.done
+	return s.transport.close()
+}
+
+// Stats returns the number of records sent, dropped and spilled since the Shipper was created.
+func (s *Shipper) Stats() ShipStats {
+	return ShipStats{Sent: s.sent.Load(), Dropped: s.dropped.Load(), Spilled: s.spilled.Load()}
+}
+
+func (s *Shipper) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.cfg.BatchSize)
+	for {
+		select {
+		case rec, ok := <-s.queue:
+			if !ok {
+				s.ship(batch)
+				s.settle()
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= s.cfg.BatchSize {
+				s.ship(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.ship(batch)
+			batch = batch[:0]
+		case <-s.retry:
+			s.retry = nil
+			s.resend()
+		case ack := <-s.flush:
+		drain:
+			for {
+				select {
+				case rec := <-s.queue:
+					batch = append(batch, rec)
+					if len(batch) >= s.cfg.BatchSize {
+						s.ship(batch)
+						batch = batch[:0]
+					}
+				default:
+					break drain
+				}
+			}
+			s.ship(batch)
+			batch = batch[:0]
+			s.settle()
+			close(ack)
+		}
+	}
+}
+
+// ship delivers batch, unless earlier records are waiting to be retried, in which case it is held
+// behind them.  Once a send succeeds any spilled records are resent.
+func (s *Shipper) ship(batch [][]byte) {
+	if len(batch) == 0 {
+		return
+	}
+	if s.retry != nil {
+		s.hold(batch)
+		return
+	}
+	rest, err := s.deliver(batch)
+	if err != nil {
+		var perm permanentError
+		if errors.As(err, &perm) {
+			s.dropped.Add(uint64(len(rest)))
+			return
+		}
+		s.hold(rest)
+		s.failed()
+		return
+	}
+	s.attempts = 0
+	if s.hasSpill {
+		s.replay()
+	}
+}
+
+// resend retries the pending records, then any spilled records.
+func (s *Shipper) resend() {
+	for len(s.pending) > 0 {
+		n := s.cfg.BatchSize
+		if n > len(s.pending) {
+			n = len(s.pending)
+		}
+		rest, err := s.deliver(s.pending[:n])
+		var perm permanentError
+		switch {
+		case err == nil:
+		case errors.As(err, &perm):
+			s.dropped.Add(uint64(len(rest)))
+		default:
+			s.pending = s.pending[n-len(rest):]
+			s.failed()
+			return
+		}
+		s.pending = s.pending[n:]
+		s.attempts = 0
+	}
+	s.pending = nil
+	if s.hasSpill {
+		s.replay()
+	}
+}
+
+// failed records a failed attempt and schedules a retry with jittered exponential backoff.  Once
+// the retries are used up the pending records are spilled, or dropped, and the next batch is sent
+// as normal.
+func (s *Shipper) failed() {
+	s.attempts++
+	if s.attempts > s.cfg.MaxRetries && len(s.pending) > 0 {
+		s.discard(s.pending)
+		s.pending = nil
+		s.attempts = 0
+		return
+	}
+	d := s.cfg.RetryBaseDelay << (s.attempts - 1)
+	if d <= 0 || d > s.cfg.RetryMaxDelay {
+		d = s.cfg.RetryMaxDelay
+	}
+	s.retry = time.After(d/2 + time.Duration(rand.Int63n(int64(d/2)+1)))
+}
+
+// settle waits out the backoff and retries the pending records until each has been delivered,
+// spilled or dropped.
+func (s *Shipper) settle() {
+	for len(s.pending) > 0 && s.retry != nil {
+		<-s.retry
+		s.retry = nil
+		s.resend()
+	}
+}
+
+// deliver makes one attempt to send records, returning those that were not delivered.  Records
+// the transport skipped are counted as dropped.
+func (s *Shipper) deliver(records [][]byte) ([][]byte, error) {
+	sent, skipped, err := s.transport.send(records)
+	s.sent.Add(uint64(sent))
+	s.dropped.Add(uint64(skipped))
+	return records[sent+skipped:], err
+}
+
+// hold keeps records to be retried, up to QueueSize of them, the rest are spilled or dropped.
+func (s *Shipper) hold(records [][]byte) {
+	if room := s.cfg.QueueSize - len(s.pending); room < len(records) {
+		if room < 0 {
+			room = 0
+		}
+		s.discard(records[room:])
+		records = records[:room]
+	}
+	s.pending = append(s.pending, records...)
+}
+
+// discard spills records if a SpillPath is set, otherwise drops them.
+func (s *Shipper) discard(records [][]byte) {
+	if s.cfg.SpillPath == "" {
+		s.dropped.Add(uint64(len(records)))
+		return
+	}
+	s.spill(records)
+}
+
+// spill appends records to the spill file, each prefixed with its length.
+func (s *Shipper) spill(records [][]byte) {
+	if len(records) == 0 {
+		return
+	}
+	f, err := os.OpenFile(s.cfg.SpillPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		s.dropped.Add(uint64(len(records)))
+		return
+	}
+	defer f.Close()
+	s.hasSpill = true
+	end, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		s.dropped.Add(uint64(len(records)))
+		return
+	}
+	var size [4]byte
+	for i, rec := range records {
+		binary.BigEndian.PutUint32(size[:], uint32(len(rec)))
+		_, err := f.Write(size[:])
+		if err == nil {
+			_, err = f.Write(rec)
+		}
+		if err != nil {
+			// cut off the partly written record so that it does not tear the file.
+			f.Truncate(end)
+			s.dropped.Add(uint64(len(records) - i))
+			return
+		}
+		end += int64(len(size) + len(rec))
+		s.spilled.Add(1)
+	}
+}
+
+// replay resends the records in the spill file, removing it once they have been delivered.  If the
+// endpoint fails again the undelivered records are kept in the file and retried after a backoff.
+// A record torn by a crash whilst spilling is dropped, the file being removed or rewritten without
+// it below, so that records spilled later are not appended after it.
+func (s *Shipper) replay() {
+	records, err := readSpill(s.cfg.SpillPath)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		s.dropped.Add(1)
+	case err != nil:
+		// an unreadable spill file is left in place for inspection rather than blocking new records.
+		s.hasSpill = false
+		return
+	}
+	for len(records) > 0 {
+		n := s.cfg.BatchSize
+		if n > len(records) {
+			n = len(records)
+		}
+		rest, err := s.deliver(records[:n])
+		var perm permanentError
+		switch {
+		case err == nil:
+		case errors.As(err, &perm):
+			s.dropped.Add(uint64(len(rest)))
+		default:
+			// rewrite the spill file with the records that are still undelivered.
+			records = records[n-len(rest):]
+			if werr := writeSpill(s.cfg.SpillPath, records); werr != nil {
+				s.dropped.Add(uint64(len(records)))
+				s.hasSpill = false
+			}
+			s.failed()
+			return
+		}
+		records = records[n:]
+	}
+	if err := os.Remove(s.cfg.SpillPath); err == nil || os.IsNotExist(err) {
+		s.hasSpill = false
+	}
+}
+
+func readSpill(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var records [][]byte
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(f, size[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return records, fmt.Errorf("log: reading spill file: %w", err)
+		}
+		rec := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(f, rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return records, fmt.Errorf("log: reading spill file: %w", err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// trimSpill truncates a record torn by a crash whilst spilling from the end of the spill file at path,
+// so that records spilled later are not appended after it.  It reports whether there was one.
+func trimSpill(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	var off int64
+	var size [4]byte
+	for off < info.Size() {
+		if _, err := f.ReadAt(size[:], off); err != nil {
+			break
+		}
+		end := off + int64(len(size)) + int64(binary.BigEndian.Uint32(size[:]))
+		if end > info.Size() {
+			break
+		}
+		off = end
+	}
+	if off == info.Size() {
+		return false, nil
+	}
+	return true, f.Truncate(off)
+}
+
+func writeSpill(path string, records [][]byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	var size [4]byte
+	for _, rec := range records {
+		binary.BigEndian.PutUint32(size[:], uint32(len(rec)))
+		if _, err := f.Write(size[:]); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// downTransport fails every send whilst down, recording the records it delivers.
+type downTransport struct {
+	mu   sync.Mutex
+	down bool
+	got  []string
+}
+
+func (d *downTransport) send(records [][]byte) (int, int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.down {
+		return 0, 0, errors.New("endpoint down")
+	}
+	for _, rec := range records {
+		d.got = append(d.got, string(rec))
+	}
+	return len(records), 0, nil
+}
+
+func (d *downTransport) close() error { return nil }
+
+func (d *downTransport) setDown(down bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.down = down
+}
+
+func TestShipper(t *testing.T) {
+	t.Run("retries only unsent datagrams", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Close()
+		var got []string
+		failed := false
+		tr, err := newConnTransport("udp", pc.LocalAddr().String(), nil, func(conn net.Conn, rec []byte) error {
+			if string(rec) == "b" && !failed {
+				failed = true
+				return errors.New("send failed")
+			}
+			got = append(got, string(rec))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := newShipper(tr, ShipConfig{RetryBaseDelay: time.Millisecond})
+		for _, rec := range []string{"a", "b", "c"} {
+			w.Write([]byte(rec))
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v sent once each, got %v", want, got)
+		}
+	})
+
+	t.Run("drops datagrams that can never be sent", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Close()
+		tr, err := newConnTransport("udp", pc.LocalAddr().String(), nil, func(conn net.Conn, rec []byte) error {
+			if string(rec) == "b" {
+				return errGELFTooLarge
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := newShipper(tr, ShipConfig{})
+		for _, rec := range []string{"a", "b", "c"} {
+			w.Write([]byte(rec))
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Stats(); got.Sent != 2 || got.Dropped != 1 {
+			t.Errorf("expected 2 records sent and 1 dropped, got %+v", got)
+		}
+	})
+
+	t.Run("keeps draining the queue whilst backing off", func(t *testing.T) {
+		tr := &downTransport{down: true}
+		w := newShipper(tr, ShipConfig{
+			QueueSize:      2,
+			BatchSize:      1,
+			MaxRetries:     1,
+			RetryBaseDelay: 200 * time.Millisecond,
+			SpillPath:      filepath.Join(t.TempDir(), "spill"),
+		})
+		for i := 0; i < 10; i++ {
+			w.Write([]byte(strconv.Itoa(i)))
+			time.Sleep(time.Millisecond)
+		}
+		// two records are held for retry, the rest spilled rather than dropped from a full queue.
+		for deadline := time.Now().Add(time.Second); w.Stats().Spilled < 8 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		if got := w.Stats(); got.Spilled < 8 || got.Dropped != 0 {
+			t.Fatalf("expected at least 8 records spilled and none dropped, got %+v", got)
+		}
+
+		tr.setDown(false)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Stats(); got.Sent != 10 || got.Dropped != 0 {
+			t.Errorf("expected every record sent, got %+v", got)
+		}
+		if want := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}; !reflect.DeepEqual(tr.got, want) {
+			t.Errorf("expected %v, got %v", want, tr.got)
+		}
+	})
+
+	t.Run("drops a torn record from the spill file", func(t *testing.T) {
+		spill := filepath.Join(t.TempDir(), "spill")
+		// a crash whilst spilling left a size for 10 bytes followed by only 3.
+		torn := []byte{0, 0, 0, 1, 'a', 0, 0, 0, 1, 'b', 0, 0, 0, 10, 'c', 'c', 'c'}
+		if err := os.WriteFile(spill, torn, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		tr := &downTransport{down: true}
+		w := newShipper(tr, ShipConfig{MaxRetries: 1, RetryBaseDelay: time.Millisecond, SpillPath: spill})
+		w.Write([]byte("d"))
+		w.Flush()
+		records, err := readSpill(spill)
+		if err != nil {
+			t.Fatalf("expected the torn record to be cut from the spill file, got %v", err)
+		}
+		if got := len(records); got != 3 {
+			t.Errorf("expected the new record spilled after the 2 intact ones, got %d records", got)
+		}
+
+		tr.setDown(false)
+		w.Write([]byte("e"))
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Stats(); got.Sent != 4 || got.Dropped != 1 {
+			t.Errorf("expected 4 records sent and the torn record dropped, got %+v", got)
+		}
+		if want := []string{"e", "a", "b", "d"}; !reflect.DeepEqual(tr.got, want) {
+			t.Errorf("expected %v, got %v", want, tr.got)
+		}
+	})
+}
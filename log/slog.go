@@ -0,0 +1,98 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// FromSlog returns a Logger that writes through h.  Levels are passed straight through,
+// as Level shares its values with slog.Level.
+func FromSlog(h slog.Handler) Logger {
+	return slogLogger{handler: h}
+}
+
+type slogLogger struct {
+	handler slog.Handler
+}
+
+// Log logs msg at LevelInfo.
+func (s slogLogger) Log(msg string, fields ...interface{}) error {
+	return s.LogLevel(LevelInfo, msg, fields...)
+}
+
+// LogLevel builds a slog.Record from msg and fields, which follow the slog key/value rules, and passes it to the handler.
+func (s slogLogger) LogLevel(level Level, msg string, fields ...interface{}) error {
+	ctx := context.Background()
+	if !s.handler.Enabled(ctx, slog.Level(level)) {
+		return nil
+	}
+	r := slog.NewRecord(time.Now(), slog.Level(level), msg, 0)
+	r.Add(fields...)
+	return s.handler.Handle(ctx, r)
+}
+
+// Enabled reports whether the handler is enabled for level.
+func (s slogLogger) Enabled(level Level) bool {
+	return s.handler.Enabled(context.Background(), slog.Level(level))
+}
+
+// NewSlogHandler returns a slog.Handler that writes records to l, so that a *slog.Logger can be
+// used in front of any Logger.  Attributes in groups are logged with dotted keys, e.g. "request.id".
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+type slogHandler struct {
+	logger Logger
+	fields []interface{} // fields added with WithAttrs.
+	prefix string        // dotted prefix of the open groups.
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return Enabled(h.logger, Level(level))
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]interface{}, 0, len(h.fields)+2*r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	return LogLevel(h.logger, Level(r.Level), r.Message, fields...)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append([]interface{}{}, h.fields...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	return &slogHandler{logger: h.logger, fields: fields, prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// appendAttr appends a as key/value fields, flattening groups into dotted keys.
+func appendAttr(fields []interface{}, prefix string, a slog.Attr) []interface{} {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		// an inline group has an empty key and is logged without adding to the prefix.
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	if a.Key == "" {
+		return fields
+	}
+	return append(fields, prefix+a.Key, v.Any())
+}
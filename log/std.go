@@ -0,0 +1,40 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	stdlog "log"
+)
+
+// NewWriter returns an io.Writer that logs every line written to it as the message of an entry at level.
+// It can be used to capture the output of packages that write plain text logs.
+func NewWriter(l Logger, level Level) io.Writer {
+	return lineWriter{logger: l, level: level}
+}
+
+// NewStdLogger returns a standard library *log.Logger that writes to l at level, e.g. to set as the
+// ErrorLog of a http.Server so that its errors land in the structured logs.
+func NewStdLogger(l Logger, level Level) *stdlog.Logger {
+	return stdlog.New(NewWriter(l, level), "", 0)
+}
+
+type lineWriter struct {
+	logger Logger
+	level  Level
+}
+
+// Write logs each non empty line in p.  It always reports the whole of p as written, so that callers
+// do not retry, and returns the first error from the logger.
+func (w lineWriter) Write(p []byte) (int, error) {
+	var err error
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) == 0 {
+			continue
+		}
+		if lerr := LogLevel(w.logger, w.level, string(line)); lerr != nil && err == nil {
+			err = lerr
+		}
+	}
+	return len(p), err
+}
@@ -0,0 +1,282 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog facilities, as defined by RFC 5424.
+const (
+	FacilityKern   = 0
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityLocal0 = 16
+	FacilityLocal1 = 17
+	FacilityLocal2 = 18
+	FacilityLocal3 = 19
+	FacilityLocal4 = 20
+	FacilityLocal5 = 21
+	FacilityLocal6 = 22
+	FacilityLocal7 = 23
+)
+
+// SyslogSDID is the structured data ID fields are written under.  32473 is the enterprise number
+// reserved by RFC 5612 for documentation, so the ID cannot clash with a registered one.
+const SyslogSDID = "fields@32473"
+
+// syslogTimeFormat is the RFC 5424 timestamp, limited to microsecond precision.
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// dialTimeout bounds connecting to a syslog or GELF endpoint.
+const dialTimeout = 5 * time.Second
+
+// syslogSeverity maps a level to a syslog severity, which GELF also uses.
+func syslogSeverity(level Level) int {
+	switch {
+	case level >= LevelError:
+		return 3
+	case level >= LevelWarn:
+		return 4
+	case level >= LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// SyslogEncoder writes each entry as an RFC 5424 syslog message, with the fields as structured data
+// under SyslogSDID.  The message has no terminator, framing is left to the transport, so it should be
+// used with a writer from NewSyslogWriter.
+type SyslogEncoder struct {
+	Facility int    // defaults to FacilityUser.
+	Hostname string // defaults to os.Hostname.
+	AppName  string // defaults to the program name.
+}
+
+// Encode implements Encoder.
+func (enc SyslogEncoder) Encode(buf []byte, e *Entry) []byte {
+	facility := enc.Facility
+	if facility == 0 {
+		facility = FacilityUser
+	}
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(facility*8+syslogSeverity(e.Level)), 10)
+	buf = append(buf, '>', '1', ' ')
+	buf = e.Time.AppendFormat(buf, syslogTimeFormat)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeader(buf, enc.Hostname, hostname, 255)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeader(buf, enc.AppName, appName, 48)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(pid), 10)
+	buf = append(buf, " - "...)
+	if len(e.Fields) == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = append(buf, '[')
+		buf = append(buf, SyslogSDID...)
+		for _, f := range e.Fields {
+			buf = append(buf, ' ')
+			buf = appendSyslogParamName(buf, f.Key)
+			buf = append(buf, '=', '"')
+			buf = appendSyslogParamValue(buf, f.Value)
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ']')
+	}
+	if e.Message != "" {
+		buf = append(buf, ' ')
+		buf = append(buf, e.Message...)
+	}
+	return buf
+}
+
+var (
+	hostname, _ = os.Hostname()
+	appName     = appNameFromArgs()
+	pid         = os.Getpid()
+)
+
+func appNameFromArgs() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	name := os.Args[0]
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// appendSyslogHeader appends a header field, which must be printable US-ASCII with no spaces, or "-" if it is empty.
+func appendSyslogHeader(buf []byte, s, def string, max int) []byte {
+	if s == "" {
+		s = def
+	}
+	n := 0
+	for i := 0; i < len(s) && n < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			buf = append(buf, c)
+			n++
+		}
+	}
+	if n == 0 {
+		buf = append(buf, '-')
+	}
+	return buf
+}
+
+// appendSyslogParamName appends a structured data parameter name, which is limited to 32 printable
+// US-ASCII characters other than '=', ' ', ']' and '"'.
+func appendSyslogParamName(buf []byte, key string) []byte {
+	n := 0
+	for i := 0; i < len(key) && n < 32; i++ {
+		c := key[i]
+		if c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf = append(buf, c)
+		n++
+	}
+	if n == 0 {
+		buf = append(buf, '_')
+	}
+	return buf
+}
+
+// appendSyslogParamValue appends v as a structured data parameter value, escaping '"', '\' and ']'.
+func appendSyslogParamValue(buf []byte, v any) []byte {
+	var s string
+	if str, ok := resolve(v).(string); ok {
+		s = str
+	} else {
+		s = string(appendTextValue(nil, v))
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			s = unquoted
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '"' || c == '\\' || c == ']' {
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, s[i])
+	}
+	return buf
+}
+
+// NewSyslogWriter returns a Shipper sending records to a syslog server at addr over network, which
+// is one of "udp", "tcp" or "unix" (or their variants such as "udp4" and "unixgram").  Datagram
+// networks send one record per datagram, stream networks frame records with octet counting as
+// described in RFC 6587.  Use it with a SyslogEncoder:
+//
+//	w, err := log.NewSyslogWriter("udp", "localhost:514", log.ShipConfig{})
+//	logger := log.New(log.Output(w), log.Format(log.SyslogEncoder{AppName: "api"}))
+func NewSyslogWriter(network, addr string, cfg ShipConfig) (*Shipper, error) {
+	t, err := newConnTransport(network, addr, func(buf, rec []byte) []byte {
+		buf = strconv.AppendInt(buf, int64(len(rec)), 10)
+		buf = append(buf, ' ')
+		return append(buf, rec...)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newShipper(t, cfg), nil
+}
+
+// connTransport sends records over a net.Conn, dialling on first use and again after a failed write.
+type connTransport struct {
+	network, addr string
+	stream        bool
+	frame         func(buf, rec []byte) []byte // frames a record on a stream connection.
+	datagram      func(conn net.Conn, rec []byte) error
+
+	mu   sync.Mutex
+	conn net.Conn
+	buf  []byte
+}
+
+func newConnTransport(network, addr string, frame func(buf, rec []byte) []byte, datagram func(net.Conn, []byte) error) (*connTransport, error) {
+	t := &connTransport{network: network, addr: addr, frame: frame, datagram: datagram}
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		t.stream = true
+	case "udp", "udp4", "udp6", "unixgram":
+	default:
+		return nil, fmt.Errorf("log: unsupported network %q", network)
+	}
+	if t.datagram == nil {
+		t.datagram = func(conn net.Conn, rec []byte) error {
+			_, err := conn.Write(rec)
+			return err
+		}
+	}
+	return t, nil
+}
+
+func (t *connTransport) send(records [][]byte) (int, int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		conn, err := net.DialTimeout(t.network, t.addr, dialTimeout)
+		if err != nil {
+			return 0, 0, fmt.Errorf("log: connecting to %s: %w", t.addr, err)
+		}
+		t.conn = conn
+	}
+	sent, skipped, err := t.write(records)
+	if err != nil {
+		t.conn.Close()
+		t.conn = nil
+		return sent, skipped, fmt.Errorf("log: sending to %s: %w", t.addr, err)
+	}
+	return sent, skipped, nil
+}
+
+// write sends records on the connection, returning how many were sent and skipped.  Datagrams are
+// sent one at a time, so a failure part way through only leaves the rest to retry.  A stream is written
+// at once, and as a partial write cannot be resumed on a new connection, it fails as a whole.
+func (t *connTransport) write(records [][]byte) (sent, skipped int, err error) {
+	if err := t.conn.SetWriteDeadline(time.Now().Add(dialTimeout)); err != nil {
+		return 0, 0, err
+	}
+	if !t.stream {
+		for _, rec := range records {
+			err := t.datagram(t.conn, rec)
+			switch {
+			case err == nil:
+				sent++
+			case errors.As(err, new(permanentError)):
+				// a record that can never be sent is skipped rather than failing the rest of the batch.
+				skipped++
+			default:
+				return sent, skipped, err
+			}
+		}
+		return sent, skipped, nil
+	}
+	t.buf = t.buf[:0]
+	for _, rec := range records {
+		t.buf = t.frame(t.buf, rec)
+	}
+	if _, err := t.conn.Write(t.buf); err != nil {
+		return 0, 0, err
+	}
+	return len(records), 0, nil
+}
+
+func (t *connTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
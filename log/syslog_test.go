@@ -0,0 +1,78 @@
+package log
+
+import (
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogWriter(t *testing.T) {
+	enc := SyslogEncoder{Facility: FacilityLocal0, Hostname: "web-1", AppName: "api"}
+	entry := &Entry{
+		Time:    time.Date(2023, 1, 28, 12, 0, 0, 0, time.UTC),
+		Level:   LevelWarn,
+		Message: "slow query",
+		Fields:  []Field{{"took", 2 * time.Second}, {"sql", `select "x"]`}},
+	}
+	want := `<132>1 2023-01-28T12:00:00.000000Z web-1 api ` + strconv.Itoa(os.Getpid()) +
+		` - [fields@32473 took="2s" sql="select \"x\"\]"] slow query`
+	if got := string(enc.Encode(nil, entry)); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	t.Run("udp", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Close()
+		w, err := NewSyslogWriter("udp", pc.LocalAddr().String(), ShipConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		New(Output(w), Format(enc)).Log("hello")
+		w.Flush()
+
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		b := make([]byte, 1024)
+		n, _, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b[:n]); !strings.HasPrefix(got, "<134>1 ") || !strings.HasSuffix(got, " - - hello") {
+			t.Errorf("unexpected datagram %q", got)
+		}
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		w, err := NewSyslogWriter("tcp", ln.Addr().String(), ShipConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("first"))
+		w.Write([]byte("second"))
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		if got := string(b); got != "5 first6 second" {
+			t.Errorf("expected octet counted frames, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtEncoder writes each entry as a line of space separated key=value pairs, starting with
+// time, level and msg.  Values are quoted when they contain spaces, quotes, '=' or control characters.
+// Timestamps default to RFC3339.
+type LogfmtEncoder struct {
+	EncoderConfig
+}
+
+var defaultLogfmtConfig = EncoderConfig{
+	TimeKey:    "time",
+	MessageKey: "msg",
+	LevelKey:   "level",
+	TimeFormat: time.RFC3339,
+}
+
+// Encode implements Encoder.
+func (enc LogfmtEncoder) Encode(buf []byte, e *Entry) []byte {
+	cfg := enc.merge(defaultLogfmtConfig)
+	buf = appendTextKey(buf, cfg.TimeKey)
+	buf = append(buf, '=')
+	start := len(buf)
+	buf = e.Time.AppendFormat(buf, cfg.TimeFormat)
+	if bytes.ContainsAny(buf[start:], " =\"") {
+		buf = strconv.AppendQuote(buf[:start], string(buf[start:]))
+	}
+	buf = append(buf, ' ')
+	buf = appendTextKey(buf, cfg.LevelKey)
+	buf = append(buf, '=')
+	buf = append(buf, e.Level.String()...)
+	buf = append(buf, ' ')
+	buf = appendTextKey(buf, cfg.MessageKey)
+	buf = append(buf, '=')
+	buf = appendTextString(buf, e.Message)
+	for _, f := range e.Fields {
+		buf = append(buf, ' ')
+		if cfg.reserved(f.Key) {
+			buf = append(buf, reservedKeyPrefix...)
+		}
+		buf = appendTextKey(buf, f.Key)
+		buf = append(buf, '=')
+		buf = appendTextValue(buf, f.Value)
+	}
+	return append(buf, '\n')
+}
+
+// ANSI escape codes used by the ConsoleEncoder.
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorFaint  = "\x1b[2m"
+)
+
+// consoleMessageWidth is the column the fields start at, so that they line up across entries.
+const consoleMessageWidth = 40
+
+// ConsoleEncoder writes human readable lines for a terminal: time, level, message and the
+// fields as key=value pairs, aligned across entries.  If Color is set, the level and field keys
+// are colorized with ANSI escape codes.
+type ConsoleEncoder struct {
+	Color      bool
+	TimeFormat string // layout of the entry timestamp, defaults to "2006-01-02 15:04:05.000".
+}
+
+// Encode implements Encoder.
+func (c ConsoleEncoder) Encode(buf []byte, e *Entry) []byte {
+	layout := c.TimeFormat
+	if layout == "" {
+		layout = "2006-01-02 15:04:05.000"
+	}
+	buf = c.color(buf, colorFaint)
+	buf = e.Time.AppendFormat(buf, layout)
+	buf = c.color(buf, colorReset)
+	buf = append(buf, ' ')
+
+	buf = c.color(buf, levelColor(e.Level))
+	level := e.Level.String()
+	for i := 0; i < len(level); i++ {
+		b := level[i]
+		if 'a' <= b && b <= 'z' {
+			b -= 'a' - 'A'
+		}
+		buf = append(buf, b)
+	}
+	buf = c.color(buf, colorReset)
+	for i := len(level); i < len("DEBUG")+1; i++ {
+		buf = append(buf, ' ')
+	}
+
+	start := len(buf)
+	buf = append(buf, e.Message...)
+	if len(e.Fields) > 0 {
+		for n := utf8.RuneCount(buf[start:]); n < consoleMessageWidth; n++ {
+			buf = append(buf, ' ')
+		}
+	}
+	for _, f := range e.Fields {
+		buf = append(buf, ' ')
+		buf = c.color(buf, colorFaint)
+		buf = appendTextKey(buf, f.Key)
+		buf = append(buf, '=')
+		buf = c.color(buf, colorReset)
+		buf = appendTextValue(buf, f.Value)
+	}
+	return append(buf, '\n')
+}
+
+func (c ConsoleEncoder) color(buf []byte, code string) []byte {
+	if !c.Color {
+		return buf
+	}
+	return append(buf, code...)
+}
+
+func levelColor(l Level) string {
+	switch {
+	case l >= LevelError:
+		return colorRed
+	case l >= LevelWarn:
+		return colorYellow
+	case l >= LevelInfo:
+		return colorGreen
+	default:
+		return colorBlue
+	}
+}
+
+// isTerminal reports whether w is a character device, such as a terminal.
+func isTerminal(w any) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// defaultEncoder returns the encoder for w when none has been chosen: colorized console output for
+// a terminal, unless the NO_COLOR environment variable is set, and JSON otherwise.
+func defaultEncoder(w any) Encoder {
+	if isTerminal(w) {
+		return ConsoleEncoder{Color: os.Getenv("NO_COLOR") == ""}
+	}
+	return JSONEncoder{}
+}
+
+// appendTextKey appends a logfmt key, replacing characters that would break the key=value syntax.
+func appendTextKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, `""`...)
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			r = '_'
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
+
+// appendTextString appends s, quoted if required.
+func appendTextString(buf []byte, s string) []byte {
+	if needsQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// appendTextValue appends v for the logfmt and console encoders.  Scalars are written as they would
+// be in JSON, without quotes where possible, and composite values as quoted JSON.
+func appendTextValue(buf []byte, v any) []byte {
+	switch v := resolve(v).(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendTextString(buf, v)
+	case time.Duration:
+		return append(buf, v.String()...)
+	case time.Time:
+		return v.AppendFormat(buf, time.RFC3339Nano)
+	case json.Marshaler:
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return appendTextString(buf, fmt.Sprint(v))
+		}
+		return appendTextString(buf, string(b))
+	case error:
+		return appendTextString(buf, v.Error())
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return appendJSONValue(buf, v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return appendTextString(buf, fmt.Sprint(v))
+		}
+		return appendTextString(buf, string(b))
+	}
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// LogValuer is implemented by types that control how they are logged.  The value returned by
+// LogValue is logged in place of the original, and may itself be a LogValuer.
+type LogValuer interface {
+	LogValue() any
+}
+
+// maxLogValueDepth limits how many LogValuers and Redactors are resolved, in case one returns itself.
+const maxLogValueDepth = 100
+
+// resolve returns the value that will be logged for v, calling Redact and LogValue until the result
+// is neither a Redactor nor a LogValuer.  Redact is called first, so a value is never logged unredacted.
+// A nil pointer whose type has methods the encoders call resolves to nil, as it would be marshalled
+// as null by encoding/json, rather than panicking in a method with a value receiver.
+func resolve(v any) any {
+	for i := 0; i < maxLogValueDepth; i++ {
+		switch rv := v.(type) {
+		case Redactor:
+			if isNilPointer(v) {
+				return nil
+			}
+			v = rv.Redact()
+		case LogValuer:
+			if isNilPointer(v) {
+				return nil
+			}
+			v = rv.LogValue()
+		case json.Marshaler, error:
+			if isNilPointer(v) {
+				return nil
+			}
+			return v
+		default:
+			return v
+		}
+	}
+	return v
+}
+
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
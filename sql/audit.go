@@ -0,0 +1,290 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Names of the audit columns populated by Tx.Insert, Tx.Update and Tx.SoftDelete.
+const (
+	ColumnCreatedAt = "created_at"
+	ColumnUpdatedAt = "updated_at"
+	ColumnDeletedAt = "deleted_at"
+	ColumnCreatedBy = "created_by"
+	ColumnUpdatedBy = "updated_by"
+)
+
+// DefaultAuditTable is the table change rows are written to unless changed with DB.SetAuditTable.
+const DefaultAuditTable = "audit_log"
+
+// SetAuditTable sets the table Tx.Insert, Tx.Update and Tx.SoftDelete write change rows to, or
+// DefaultAuditTable if name is empty.  The table is expected to have the columns table_name, action,
+// actor, changed_at and changes, the latter holding a JSON document.  It is not escaped and must not
+// come from user input.
+func (db *DB) SetAuditTable(name string) {
+	db.auditTable = name
+}
+
+// Actions recorded in the audit table.
+const (
+	ActionInsert = "insert"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the identity of the user acting on the database.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or an empty string.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// auditChange is the JSON document recorded in the changes column.
+type auditChange struct {
+	ID    int64          `json:"id,omitempty"`
+	Set   map[string]any `json:"set,omitempty"`
+	Where string         `json:"where,omitempty"`
+	Args  []any          `json:"args,omitempty"`
+}
+
+// Insert inserts a row of values into table, populating the created and updated columns from tx.Now()
+// and the actor in ctx, and records the change in the audit table.
+// Table and column names are not escaped and must not come from user input.
+func (tx *Tx) Insert(ctx context.Context, table string, values map[string]any) (sql.Result, error) {
+	actor := ActorFromContext(ctx)
+	values = withColumns(values, map[string]any{
+		ColumnCreatedAt: tx.Now(),
+		ColumnUpdatedAt: tx.Now(),
+		ColumnCreatedBy: actor,
+		ColumnUpdatedBy: actor,
+	})
+	cols := sortedKeys(values)
+	query, args := insertQuery(tx.dialect(), table, cols, values)
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sql: inserting into %s: %w", table, err)
+	}
+	change := auditChange{Set: values}
+	// not every driver supports LastInsertId, the change is still recorded without it.
+	change.ID, _ = res.LastInsertId()
+	if err := tx.audit(ctx, table, ActionInsert, actor, change); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Update sets values on the rows of table matching where, populating the updated columns from tx.Now()
+// and the actor in ctx, and records the change in the audit table.  Soft deleted rows are not updated.
+// The where clause uses the placeholders of the DBs dialect, numbered from 1 for postgres.
+func (tx *Tx) Update(ctx context.Context, table string, values map[string]any, where string, args ...any) (sql.Result, error) {
+	actor := ActorFromContext(ctx)
+	values = withColumns(values, map[string]any{
+		ColumnUpdatedAt: tx.Now(),
+		ColumnUpdatedBy: actor,
+	})
+	query, queryArgs := updateQuery(tx.dialect(), table, sortedKeys(values), values, where, args)
+	res, err := tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("sql: updating %s: %w", table, err)
+	}
+	if err := tx.audit(ctx, table, ActionUpdate, actor, auditChange{Set: values, Where: where, Args: args}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SoftDelete marks the rows of table matching where as deleted by setting their deleted_at column,
+// and records the change in the audit table.
+func (tx *Tx) SoftDelete(ctx context.Context, table string, where string, args ...any) (sql.Result, error) {
+	actor := ActorFromContext(ctx)
+	values := map[string]any{
+		ColumnDeletedAt: tx.Now(),
+		ColumnUpdatedAt: tx.Now(),
+		ColumnUpdatedBy: actor,
+	}
+	query, queryArgs := updateQuery(tx.dialect(), table, sortedKeys(values), values, where, args)
+	res, err := tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("sql: deleting from %s: %w", table, err)
+	}
+	if err := tx.audit(ctx, table, ActionDelete, actor, auditChange{Set: values, Where: where, Args: args}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// audit writes a change row to the audit table within the Tx.
+func (tx *Tx) audit(ctx context.Context, table, action, actor string, change auditChange) error {
+	changes, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("sql: marshalling audit changes: %w", err)
+	}
+	values := map[string]any{
+		"table_name": table,
+		"action":     action,
+		"actor":      actor,
+		"changed_at": tx.Now(),
+		"changes":    string(changes),
+	}
+	query, args := insertQuery(tx.dialect(), tx.auditTable(), sortedKeys(values), values)
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("sql: writing audit row: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) auditTable() string {
+	if tx.db == nil || tx.db.auditTable == "" {
+		return DefaultAuditTable
+	}
+	return tx.db.auditTable
+}
+
+func (tx *Tx) dialect() string {
+	if tx.db == nil {
+		return ""
+	}
+	return tx.db.cfg.Dialect
+}
+
+var (
+	whereClause = regexp.MustCompile(`(?i)\bwhere\b`)
+	// tailClause matches the clauses that may follow a where clause.
+	tailClause = regexp.MustCompile(`(?i)\b(group\s+by|having|order\s+by|limit|offset|for\s+update|for\s+share)\b`)
+)
+
+// NotDeleted adds a "deleted_at IS NULL" filter to the where clause of a simple select query, adding a
+// where clause if there is none.  Keywords within string literals, quoted identifiers and qualified
+// names such as "p.limit" are ignored.  Queries containing subqueries or unions should add the filter by hand.
+func NotDeleted(query string) string {
+	filter := ColumnDeletedAt + " IS NULL"
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	masked := maskQuoted(query)
+
+	end := len(query)
+	if loc := findKeyword(whereClause, masked, 0); loc != nil {
+		if tail := findKeyword(tailClause, masked, loc[1]); tail != nil {
+			end = tail[0]
+		}
+		cond := strings.TrimSpace(query[loc[1]:end])
+		return strings.TrimSpace(query[:loc[1]] + " " + filter + " AND (" + cond + ") " + query[end:])
+	}
+	if tail := findKeyword(tailClause, masked, 0); tail != nil {
+		end = tail[0]
+	}
+	return strings.TrimSpace(strings.TrimSpace(query[:end]) + " WHERE " + filter + " " + query[end:])
+}
+
+// findKeyword returns the location in masked of the first match of re from offset that is not
+// part of a qualified name, or nil.
+func findKeyword(re *regexp.Regexp, masked string, offset int) []int {
+	for _, loc := range re.FindAllStringIndex(masked[offset:], -1) {
+		start := offset + loc[0]
+		if start > 0 && masked[start-1] == '.' {
+			continue
+		}
+		return []int{start, offset + loc[1]}
+	}
+	return nil
+}
+
+// maskQuoted returns query with the contents of string literals and quoted identifiers replaced
+// by spaces, so offsets into the result are offsets into query.  Quotes are escaped by doubling
+// them, or in string literals with a backslash as in mysql.
+func maskQuoted(query string) string {
+	b := []byte(query)
+	var quote byte
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case quote == 0:
+			if c == '\'' || c == '"' || c == '`' {
+				quote = c
+			}
+		case c == quote:
+			// a doubled quote closes the literal and immediately opens it again.
+			quote = 0
+		case c == '\\' && quote == '\'' && i+1 < len(b):
+			b[i], b[i+1] = ' ', ' '
+			i++
+		default:
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// placeholder returns the nth (from 1) bind parameter for the dialect.
+func placeholder(dialect string, n int) string {
+	if dialect == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func insertQuery(dialect, table string, cols []string, values map[string]any) (string, []any) {
+	params := make([]string, len(cols))
+	args := make([]any, len(cols))
+	for i, col := range cols {
+		params[i] = placeholder(dialect, i+1)
+		args[i] = values[col]
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), strings.Join(params, ", "))
+	return query, args
+}
+
+// updateQuery builds an update statement that skips soft deleted rows.  Postgres placeholders
+// in where are left as written, so the set parameters are numbered after them.
+func updateQuery(dialect, table string, cols []string, values map[string]any, where string, whereArgs []any) (string, []any) {
+	offset := 0
+	if dialect == "postgres" {
+		offset = len(whereArgs)
+	}
+	sets := make([]string, len(cols))
+	setArgs := make([]any, len(cols))
+	for i, col := range cols {
+		sets[i] = col + " = " + placeholder(dialect, offset+i+1)
+		setArgs[i] = values[col]
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(sets, ", "))
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query = NotDeleted(query)
+
+	if dialect == "postgres" {
+		return query, append(append([]any{}, whereArgs...), setArgs...)
+	}
+	return query, append(setArgs, whereArgs...)
+}
+
+// withColumns returns a copy of values with the audit columns added.
+func withColumns(values map[string]any, cols map[string]any) map[string]any {
+	out := make(map[string]any, len(values)+len(cols))
+	for k, v := range values {
+		out[k] = v
+	}
+	for k, v := range cols {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
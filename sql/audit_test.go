@@ -0,0 +1,134 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNotDeleted(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "SELECT * FROM users",
+			want:  "SELECT * FROM users WHERE deleted_at IS NULL",
+		},
+		{
+			query: "SELECT * FROM users WHERE id = ? OR email = ?;",
+			want:  "SELECT * FROM users WHERE deleted_at IS NULL AND (id = ? OR email = ?)",
+		},
+		{
+			query: "select * from users where active = 1 order by id limit 10",
+			want:  "select * from users where deleted_at IS NULL AND (active = 1) order by id limit 10",
+		},
+		{
+			query: "SELECT * FROM users ORDER BY id",
+			want:  "SELECT * FROM users WHERE deleted_at IS NULL ORDER BY id",
+		},
+		{
+			query: "SELECT * FROM notes WHERE body = 'see where it''s ordered by' LIMIT 1",
+			want:  "SELECT * FROM notes WHERE deleted_at IS NULL AND (body = 'see where it''s ordered by') LIMIT 1",
+		},
+		{
+			query: `SELECT * FROM notes WHERE body = 'it\'s the limit' AND "offset" = 2`,
+			want:  `SELECT * FROM notes WHERE deleted_at IS NULL AND (body = 'it\'s the limit' AND "offset" = 2)`,
+		},
+		{
+			query: "SELECT p.`limit`, p.offset FROM pages p WHERE p.offset > ? ORDER BY p.limit",
+			want:  "SELECT p.`limit`, p.offset FROM pages p WHERE deleted_at IS NULL AND (p.offset > ?) ORDER BY p.limit",
+		},
+		{
+			query: "SELECT page_limit FROM pages",
+			want:  "SELECT page_limit FROM pages WHERE deleted_at IS NULL",
+		},
+	}
+	for _, tt := range tests {
+		if got := NotDeleted(tt.query); got != tt.want {
+			t.Errorf("NotDeleted(%q)\nexpected %q\ngot      %q", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestUpdateQuery(t *testing.T) {
+	values := map[string]any{"name": "gopher", "updated_by": "admin"}
+	cols := sortedKeys(values)
+
+	t.Run("mysql", func(t *testing.T) {
+		query, args := updateQuery("mysql", "users", cols, values, "id = ?", []any{7})
+		want := "UPDATE users SET name = ?, updated_by = ? WHERE deleted_at IS NULL AND (id = ?)"
+		if query != want {
+			t.Errorf("expected %q, got %q", want, query)
+		}
+		if wantArgs := []any{"gopher", "admin", 7}; !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("expected args %v, got %v", wantArgs, args)
+		}
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		query, args := updateQuery("postgres", "users", cols, values, "id = $1", []any{7})
+		want := "UPDATE users SET name = $2, updated_by = $3 WHERE deleted_at IS NULL AND (id = $1)"
+		if query != want {
+			t.Errorf("expected %q, got %q", want, query)
+		}
+		if wantArgs := []any{7, "gopher", "admin"}; !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("expected args %v, got %v", wantArgs, args)
+		}
+	})
+}
+
+func TestAudit(t *testing.T) {
+	stub := &stubDriver{}
+	sql.Register(t.Name(), stub)
+	sqlDB, err := sql.Open(t.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db := &DB{DB: sqlDB}
+	db.SetAuditTable("changes")
+	ctx := WithActor(context.Background(), "admin")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Insert(ctx, "users", map[string]any{"name": "gopher"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Update(ctx, "users", map[string]any{"name": "gopher"}, "id = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.SoftDelete(ctx, "users", "id = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := "INSERT INTO changes (action, actor, changed_at, changes, table_name) VALUES (?, ?, ?, ?, ?)"
+	want := []string{
+		"BEGIN",
+		"INSERT INTO users (created_at, created_by, name, updated_at, updated_by) VALUES (?, ?, ?, ?, ?)",
+		audit,
+		"UPDATE users SET name = ?, updated_at = ?, updated_by = ? WHERE deleted_at IS NULL AND (id = ?)",
+		audit,
+		"UPDATE users SET deleted_at = ?, updated_at = ?, updated_by = ? WHERE deleted_at IS NULL AND (id = ?)",
+		audit,
+		"COMMIT",
+	}
+	if len(stub.events) == 0 {
+		t.Fatal("expected statements to be executed")
+	}
+	// every statement must run on the connection the transaction began on.
+	conn, _, _ := strings.Cut(stub.events[0], ": ")
+	for i := range want {
+		want[i] = conn + ": " + want[i]
+	}
+	if !reflect.DeepEqual(stub.events, want) {
+		t.Errorf("expected statements\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(stub.events, "\n"))
+	}
+}
@@ -2,6 +2,8 @@ package sql
 
 import (
 	"fmt"
+
+	"github.com/goaferlx/go-core/log"
 )
 
 // Config provides a database configuration.
@@ -33,6 +35,23 @@ func (cfg Config) DSN() string {
 	}
 }
 
+// redactedConfig has the fields and json tags of Config, without its methods, so that it is
+// not redacted again.
+type redactedConfig Config
+
+// Redact implements log.Redactor so that a Config can be logged without its password.
+func (cfg Config) Redact() any {
+	if cfg.Password != "" {
+		cfg.Password = log.Redacted
+	}
+	return redactedConfig(cfg)
+}
+
+// String implements fmt.Stringer, printing the config without its password.
+func (cfg Config) String() string {
+	return fmt.Sprintf("%+v", cfg.Redact())
+}
+
 func DefaultConfig() Config {
 	return Config{
 		User:     "root",
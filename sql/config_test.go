@@ -0,0 +1,24 @@
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goaferlx/go-core/log"
+)
+
+func TestConfigRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Password = "hunter2"
+	log.New(log.Output(&buf)).Log("opening db", "cfg", cfg)
+
+	if got := buf.String(); strings.Contains(got, "hunter2") || !strings.Contains(got, `"user":"root"`) {
+		t.Errorf("expected config without password, got %s", got)
+	}
+	if got := fmt.Sprint(cfg); strings.Contains(got, "hunter2") {
+		t.Errorf("expected config to print without password, got %s", got)
+	}
+}
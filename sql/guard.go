@@ -0,0 +1,64 @@
+package sql
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrDestructiveNotAllowed is returned when a destructive operation is refused by the DBs ResetGuard.
+var ErrDestructiveNotAllowed = errors.New("sql: destructive operation not allowed")
+
+// DefaultHostPattern matches hosts that are assumed to be local development or test databases:
+// loopback hosts, and hosts with "dev" or "test" as a whole word of their name, separated by dots
+// or hyphens, such as "mysql-test" or "db.dev.internal", but not "latest-prod-db".
+var DefaultHostPattern = regexp.MustCompile(`^(localhost|127\.0\.0\.1|::1)$|(^|[.-])(dev|test)([.-]|$)`)
+
+// ResetGuard protects a DB from destructive operations, such as DestructiveReset, being run against
+// the wrong database.  Every check must pass for the operation to go ahead.
+type ResetGuard struct {
+	Environment         string         // environment the process is running in, e.g. "test".
+	AllowedEnvironments []string       // environments destructive operations may run in.
+	ConfirmToken        string         // token the caller must pass to confirm the operation, must not be empty.
+	HostPattern         *regexp.Regexp // hosts destructive operations may run against, DefaultHostPattern if nil.
+}
+
+// SetResetGuard sets the guard consulted by destructive operations.  Without a guard all destructive
+// operations are refused.
+func (db *DB) SetResetGuard(g ResetGuard) {
+	if g.HostPattern == nil {
+		g.HostPattern = DefaultHostPattern
+	}
+	db.guard = &g
+}
+
+// checkGuard returns nil if the destructive operation op may run, confirmed by the token supplied by the caller.
+func (db *DB) checkGuard(op, token string) error {
+	g := db.guard
+	if g == nil {
+		return fmt.Errorf("%w: %s: no reset guard set", ErrDestructiveNotAllowed, op)
+	}
+	if !g.allowedEnvironment() {
+		return fmt.Errorf("%w: %s: environment %q is not allowed", ErrDestructiveNotAllowed, op, g.Environment)
+	}
+	if g.ConfirmToken == "" || subtle.ConstantTimeCompare([]byte(g.ConfirmToken), []byte(token)) != 1 {
+		return fmt.Errorf("%w: %s: confirmation token does not match", ErrDestructiveNotAllowed, op)
+	}
+	if !g.HostPattern.MatchString(db.cfg.Host) {
+		return fmt.Errorf("%w: %s: host %q does not match %s", ErrDestructiveNotAllowed, op, db.cfg.Host, g.HostPattern)
+	}
+	return nil
+}
+
+func (g *ResetGuard) allowedEnvironment() bool {
+	if g.Environment == "" {
+		return false
+	}
+	for _, env := range g.AllowedEnvironments {
+		if env == g.Environment {
+			return true
+		}
+	}
+	return false
+}
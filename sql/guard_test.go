@@ -0,0 +1,50 @@
+package sql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckGuard(t *testing.T) {
+	guard := ResetGuard{
+		Environment:         "test",
+		AllowedEnvironments: []string{"development", "test"},
+		ConfirmToken:        "yes-really",
+	}
+	tests := []struct {
+		name    string
+		guard   *ResetGuard
+		env     string
+		host    string
+		token   string
+		allowed bool
+	}{
+		{name: "allowed", guard: &guard, env: "test", host: "localhost", token: "yes-really", allowed: true},
+		{name: "no guard", host: "localhost", token: "yes-really"},
+		{name: "wrong environment", guard: &guard, env: "production", host: "localhost", token: "yes-really"},
+		{name: "wrong token", guard: &guard, env: "test", host: "localhost", token: "yes"},
+		{name: "production host", guard: &guard, env: "test", host: "db.prod.internal", token: "yes-really"},
+		{name: "test host", guard: &guard, env: "test", host: "mysql-test", token: "yes-really", allowed: true},
+		{name: "dev domain", guard: &guard, env: "test", host: "db.dev.internal", token: "yes-really", allowed: true},
+		{name: "test within a word", guard: &guard, env: "test", host: "latest-prod-db", token: "yes-really"},
+		{name: "dev within a word", guard: &guard, env: "test", host: "devices-prod.example.com", token: "yes-really"},
+		{name: "loopback prefix", guard: &guard, env: "test", host: "localhost.prod.example.com", token: "yes-really"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &DB{cfg: Config{Host: tt.host}}
+			if tt.guard != nil {
+				g := *tt.guard
+				g.Environment = tt.env
+				db.SetResetGuard(g)
+			}
+			err := db.checkGuard("reset", tt.token)
+			if tt.allowed && err != nil {
+				t.Errorf("expected nil error, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrDestructiveNotAllowed) {
+				t.Errorf("expected ErrDestructiveNotAllowed, got %v", err)
+			}
+		})
+	}
+}
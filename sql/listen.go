@@ -0,0 +1,237 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goaferlx/go-core/log"
+	"github.com/lib/pq"
+)
+
+// Default reconnect backoff and keepalive for a Listener.
+const (
+	DefaultListenMinReconnect time.Duration = 1 * time.Second
+	DefaultListenMaxReconnect time.Duration = 1 * time.Minute
+	DefaultListenPingInterval time.Duration = 90 * time.Second
+)
+
+// ErrUnsupportedDialect is returned when a feature is not available for the DBs dialect.
+var ErrUnsupportedDialect = errors.New("sql: unsupported dialect")
+
+// ErrListenerClosed is returned when subscribing to a Listener that has been closed.
+var ErrListenerClosed = errors.New("sql: listener closed")
+
+// Notification is a message received through postgres NOTIFY on a channel the Listener subscribed to.
+type Notification struct {
+	Channel string
+	Payload []byte // payload as sent, nil if it was empty.
+}
+
+// Decode unmarshals the payload, which Publish sends as JSON, into v.
+func (n Notification) Decode(v any) error {
+	if err := json.Unmarshal(n.Payload, v); err != nil {
+		return fmt.Errorf("sql: decoding notification on %s: %w", n.Channel, err)
+	}
+	return nil
+}
+
+// ListenerOption configures a Listener.
+type ListenerOption func(*Listener)
+
+// OnReconnect sets fn to be called after the connection has been re-established, before any further
+// notifications are delivered, e.g. to resync state that may have changed whilst it was down.
+func OnReconnect(fn func()) ListenerOption {
+	return func(l *Listener) {
+		l.onReconnect = fn
+	}
+}
+
+// pqListener is the part of *pq.Listener used by a Listener.
+type pqListener interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Ping() error
+	Close() error
+}
+
+// Listener subscribes to postgres channels through LISTEN/NOTIFY, reconnecting with exponential backoff
+// whenever the connection is lost.  It uses a dedicated connection, outside of the DBs pool.
+// Notifications sent whilst the connection is down are lost, the OnReconnect option can be used to resync.
+type Listener struct {
+	pq          pqListener
+	logger      log.Logger
+	onReconnect func()
+	mu          sync.Mutex
+	handlers    map[string][]func(Notification)
+	chans       []chan Notification // channels returned by Notify, closed by Close.
+	closed      bool
+	done        chan struct{}
+	wg          sync.WaitGroup
+	once        sync.Once
+}
+
+// NewListener opens a Listener using the DBs config.  It is only supported by the postgres dialect.
+func (db *DB) NewListener(opts ...ListenerOption) (*Listener, error) {
+	if db.cfg.Dialect != "postgres" {
+		return nil, fmt.Errorf("%w: listen requires postgres, got %q", ErrUnsupportedDialect, db.cfg.Dialect)
+	}
+	l := newListener(db, opts)
+	l.start(pq.NewListener(db.cfg.DSN(), DefaultListenMinReconnect, DefaultListenMaxReconnect, l.event))
+	return l, nil
+}
+
+func newListener(logger log.Logger, opts []ListenerOption) *Listener {
+	l := &Listener{
+		logger:   logger,
+		handlers: make(map[string][]func(Notification)),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// start begins delivering the notifications received by conn.
+func (l *Listener) start(conn pqListener) {
+	l.pq = conn
+	l.wg.Add(1)
+	go l.run()
+}
+
+// Subscribe calls fn, in the order they were subscribed, for every notification received on channel.
+// Callbacks are run on the Listeners goroutine, a slow callback delays every other delivery.
+func (l *Listener) Subscribe(channel string, fn func(Notification)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.subscribe(channel, fn)
+}
+
+// subscribe must be called with l.mu held.
+func (l *Listener) subscribe(channel string, fn func(Notification)) error {
+	if l.closed {
+		return ErrListenerClosed
+	}
+	if _, ok := l.handlers[channel]; !ok {
+		if err := l.pq.Listen(channel); err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			return fmt.Errorf("sql: listening on %s: %w", channel, err)
+		}
+	}
+	l.handlers[channel] = append(l.handlers[channel], fn)
+	return nil
+}
+
+// Notify returns a Go channel that receives every notification on channel, which is closed when the
+// Listener is closed.  The receiver must keep up, as delivery blocks until the notification is
+// received or the Listener is closed.
+func (l *Listener) Notify(channel string, buffer int) (<-chan Notification, error) {
+	ch := make(chan Notification, buffer)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.subscribe(channel, func(n Notification) {
+		select {
+		case ch <- n:
+		case <-l.done:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.chans = append(l.chans, ch)
+	return ch, nil
+}
+
+// Close stops the Listener, closes its connection and every channel returned by Notify.
+func (l *Listener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		err = l.pq.Close()
+		// once run has returned nothing sends on the channels.
+		l.wg.Wait()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.closed = true
+		for _, ch := range l.chans {
+			close(ch)
+		}
+		l.chans = nil
+	})
+	return err
+}
+
+// run delivers notifications until the Listener is closed, pinging the connection when it is idle.
+func (l *Listener) run() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(DefaultListenPingInterval)
+	defer ticker.Stop()
+	notifications := l.pq.NotificationChannel()
+	for {
+		select {
+		case <-l.done:
+			return
+		case n, ok := <-notifications:
+			if !ok {
+				return
+			}
+			// a nil notification signals the connection was re-established.
+			if n == nil {
+				if l.onReconnect != nil {
+					l.onReconnect()
+				}
+				continue
+			}
+			l.deliver(n)
+		case <-ticker.C:
+			go l.pq.Ping()
+		}
+	}
+}
+
+func (l *Listener) deliver(n *pq.Notification) {
+	l.mu.Lock()
+	handlers := l.handlers[n.Channel]
+	l.mu.Unlock()
+
+	notification := Notification{Channel: n.Channel}
+	if n.Extra != "" {
+		notification.Payload = []byte(n.Extra)
+	}
+	for _, fn := range handlers {
+		fn(notification)
+	}
+}
+
+// event logs changes to the state of the Listeners connection.  Losing the connection is a warning,
+// as it is retried, failing to get it back is an error.
+func (l *Listener) event(ev pq.ListenerEventType, err error) {
+	switch ev {
+	case pq.ListenerEventConnected:
+		l.logger.Log("listener connected")
+	case pq.ListenerEventDisconnected:
+		log.Warn(l.logger, "listener disconnected", "error", err)
+	case pq.ListenerEventReconnected:
+		l.logger.Log("listener reconnected")
+	case pq.ListenerEventConnectionAttemptFailed:
+		log.Error(l.logger, "listener connection attempt failed", "error", err)
+	}
+}
+
+// Publish sends payload, marshalled as JSON, to channel using pg_notify.
+func (db *DB) Publish(ctx context.Context, channel string, payload any) error {
+	if db.cfg.Dialect != "postgres" {
+		return fmt.Errorf("%w: publish requires postgres, got %q", ErrUnsupportedDialect, db.cfg.Dialect)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("sql: marshalling notification: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, string(b)); err != nil {
+		return fmt.Errorf("sql: notifying %s: %w", channel, err)
+	}
+	return nil
+}
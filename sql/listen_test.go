@@ -0,0 +1,172 @@
+package sql
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goaferlx/go-core/log"
+	"github.com/goaferlx/go-core/log/logtest"
+	"github.com/lib/pq"
+)
+
+// fakeListener is a pqListener fed by the test through its notify channel.
+type fakeListener struct {
+	notify chan *pq.Notification
+
+	mu       sync.Mutex
+	channels []string
+	closed   bool
+}
+
+func newFakeListener() *fakeListener {
+	return &fakeListener{notify: make(chan *pq.Notification)}
+}
+
+func (f *fakeListener) Listen(channel string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channels = append(f.channels, channel)
+	return nil
+}
+
+func (f *fakeListener) NotificationChannel() <-chan *pq.Notification { return f.notify }
+func (f *fakeListener) Ping() error                                  { return nil }
+
+func (f *fakeListener) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func TestListener(t *testing.T) {
+	logger := logtest.New()
+
+	t.Run("subscribe and deliver", func(t *testing.T) {
+		conn := newFakeListener()
+		l := newListener(logger, nil)
+		l.start(conn)
+		defer l.Close()
+
+		var got []Notification
+		if err := l.Subscribe("users", func(n Notification) { got = append(got, n) }); err != nil {
+			t.Fatal(err)
+		}
+		ch, err := l.Notify("users", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.Notify("orders", 1); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"users", "orders"}; len(conn.channels) != 2 || conn.channels[0] != want[0] || conn.channels[1] != want[1] {
+			t.Errorf("expected to listen on %v once each, got %v", want, conn.channels)
+		}
+
+		conn.notify <- &pq.Notification{Channel: "users", Extra: `{"id":7}`}
+		n := <-ch
+		var payload struct{ ID int }
+		if err := n.Decode(&payload); err != nil || payload.ID != 7 {
+			t.Errorf("expected payload with id 7, got %+v, %v", payload, err)
+		}
+		if len(got) != 1 || got[0].Channel != "users" {
+			t.Errorf("expected 1 notification on users, got %+v", got)
+		}
+	})
+
+	t.Run("payloads", func(t *testing.T) {
+		conn := newFakeListener()
+		l := newListener(logger, nil)
+		l.start(conn)
+		defer l.Close()
+		ch, err := l.Notify("events", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conn.notify <- &pq.Notification{Channel: "events"}
+		conn.notify <- &pq.Notification{Channel: "events", Extra: "refresh"}
+		if n := <-ch; n.Payload != nil {
+			t.Errorf("expected nil payload for an empty notification, got %q", n.Payload)
+		}
+		n := <-ch
+		if string(n.Payload) != "refresh" {
+			t.Errorf("expected raw payload %q, got %q", "refresh", n.Payload)
+		}
+		var v any
+		if err := n.Decode(&v); err == nil {
+			t.Errorf("expected an error decoding a payload that is not json")
+		}
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		conn := newFakeListener()
+		reconnected := make(chan struct{}, 1)
+		l := newListener(logger, []ListenerOption{OnReconnect(func() { reconnected <- struct{}{} })})
+		l.start(conn)
+		defer l.Close()
+		ch, err := l.Notify("users", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conn.notify <- nil
+		conn.notify <- &pq.Notification{Channel: "users"}
+		select {
+		case <-reconnected:
+		default:
+			t.Fatal("expected OnReconnect to be called before the next notification")
+		}
+		<-ch
+	})
+
+	t.Run("close", func(t *testing.T) {
+		conn := newFakeListener()
+		l := newListener(logger, nil)
+		l.start(conn)
+		ch, err := l.Notify("users", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Errorf("expected no notification after Close")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected Notify channel to be closed by Close")
+		}
+		if !conn.closed {
+			t.Errorf("expected the connection to be closed")
+		}
+		if _, err := l.Notify("users", 0); !errors.Is(err, ErrListenerClosed) {
+			t.Errorf("expected ErrListenerClosed, got %v", err)
+		}
+		if err := l.Close(); err != nil {
+			t.Errorf("expected second Close to return nil, got %v", err)
+		}
+	})
+	t.Run("events", func(t *testing.T) {
+		logger := logtest.New()
+		l := newListener(logger, nil)
+		lost := errors.New("connection reset")
+		l.event(pq.ListenerEventConnected, nil)
+		l.event(pq.ListenerEventDisconnected, lost)
+		l.event(pq.ListenerEventConnectionAttemptFailed, lost)
+
+		for msg, level := range map[string]log.Level{
+			"listener connected":                 log.LevelInfo,
+			"listener disconnected":              log.LevelWarn,
+			"listener connection attempt failed": log.LevelError,
+		} {
+			if got := logger.Entries(logtest.WithMessage(msg), logtest.AtLevel(level)); len(got) != 1 {
+				t.Errorf("expected %q to be logged at %s, got %v", msg, level, logger.Entries(logtest.WithMessage(msg)))
+			}
+		}
+	})
+}
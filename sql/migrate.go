@@ -3,8 +3,12 @@ package sql
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/goaferlx/go-core/log"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
@@ -21,7 +25,12 @@ func (db *DB) MigrateUp() error {
 	return nil
 }
 
-func (db *DB) MigrateDown() error {
+// MigrateDown reverts every migration, dropping the schema.  It is a destructive operation and
+// is refused unless allowed by the DBs ResetGuard and confirmed with its token.
+func (db *DB) MigrateDown(confirm string) error {
+	if err := db.checkGuard("migrate down", confirm); err != nil {
+		return err
+	}
 	m, err := db.migrator(db.path)
 	if err != nil {
 		return fmt.Errorf("sql: creating migrator: %w", err)
@@ -34,7 +43,12 @@ func (db *DB) MigrateDown() error {
 	return nil
 }
 
-func (db *DB) DestructiveReset() error {
+// DestructiveReset reverts every migration and re-applies them, leaving an empty schema.
+// It is refused unless allowed by the DBs ResetGuard and confirmed with its token.
+func (db *DB) DestructiveReset(confirm string) error {
+	if err := db.checkGuard("destructive reset", confirm); err != nil {
+		return err
+	}
 	m, err := db.migrator(db.path)
 	if err != nil {
 		return fmt.Errorf("sql: creating migrator: %w", err)
@@ -57,11 +71,82 @@ func (db *DB) SetMigrationPath(pathToMigrationFiles string) {
 	db.path = pathToMigrationFiles
 }
 
+// SetMigrationLockTimeout sets how long a migration waits for the lock held by another process,
+// e.g. a second pod starting at the same time, before giving up with migrate.ErrLockTimeout.
+// Defaults to migrate.DefaultLockTimeout.  The mysql driver gives up on the lock after 10 seconds
+// with GET_LOCK(?, 10), so the lock is tried again until the timeout, which is rounded up to the
+// end of the attempt running when it expires.
+func (db *DB) SetMigrationLockTimeout(d time.Duration) {
+	db.lockTimeout = d
+}
+
+// mysqlLockWait is how long each attempt of the mysql driver waits for the migration lock.
+const mysqlLockWait = 10 * time.Second
+
+// lockRetryDelay is the pause between attempts to take the migration lock.
+const lockRetryDelay = 100 * time.Millisecond
+
 func (db *DB) migrator(filePath string) (*migrate.Migrate, error) {
 	instance, err := mysql.WithInstance(db.DB, &mysql.Config{})
 	if err != nil {
 		return nil, fmt.Errorf("sql: creating db instance: %w", err)
 	}
-	return migrate.NewWithDatabaseInstance(filePath, "mysql", instance)
+	return db.newMigrate(filePath, instance)
+}
+
+// newMigrate returns a migrator for the files at filePath applied to instance, configured from the DB.
+func (db *DB) newMigrate(filePath string, instance database.Driver) (*migrate.Migrate, error) {
+	timeout := db.lockTimeout
+	if timeout <= 0 {
+		timeout = migrate.DefaultLockTimeout
+	}
+	m, err := migrate.NewWithDatabaseInstance(filePath, "mysql", &lockWaiter{Driver: instance, timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	// the lockWaiter enforces the timeout, migrate's own must not expire during its last attempt.
+	m.LockTimeout = timeout + mysqlLockWait
+	m.Log = migrateLogger{log.Named(db.logger(), MigrateLoggerName)}
+	return m, nil
+}
+
+// lockWaiter retries taking the lock of a database driver whilst it is held elsewhere, until timeout.
+type lockWaiter struct {
+	database.Driver
+	timeout time.Duration
+}
+
+// Lock implements database.Driver.
+func (w *lockWaiter) Lock() error {
+	deadline := time.Now().Add(w.timeout)
+	for {
+		err := w.Driver.Lock()
+		if !errors.Is(err, database.ErrLocked) {
+			return err
+		}
+		if time.Now().Add(lockRetryDelay).After(deadline) {
+			return migrate.ErrLockTimeout
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// MigrateLoggerName is the name migrations are logged under, see log.Named.  The progress of each
+// migration is only logged when log.LevelDebug is enabled for the name, so it can be made verbose,
+// with log.Levels, whilst other logging stays at info.
+const MigrateLoggerName = "db.migrate"
+
+// migrateLogger adapts a log.Logger to the migrate.Logger interface.
+type migrateLogger struct {
+	log.Logger
+}
+
+// Printf logs a message from migrate at log.LevelInfo.
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	log.Info(l.Logger, strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
 
+// Verbose reports whether debug entries are enabled, migrate only logs the progress of each migration if they are.
+func (l migrateLogger) Verbose() bool {
+	return log.Enabled(l.Logger, log.LevelDebug)
 }
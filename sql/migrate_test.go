@@ -0,0 +1,78 @@
+package sql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goaferlx/go-core/log"
+	"github.com/goaferlx/go-core/log/logtest"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+func TestMigrateLogger(t *testing.T) {
+	levels := log.NewLevels(log.LevelInfo)
+	logger := logtest.New(log.MinLevel(levels))
+	db := &DB{Logger: logger}
+	m := migrateLogger{log.Named(db.logger(), MigrateLoggerName)}
+
+	if m.Verbose() {
+		t.Errorf("expected migrations not to be verbose at info")
+	}
+	levels.Var("db").Set(log.LevelDebug)
+	if !m.Verbose() {
+		t.Errorf("expected migrations to be verbose once debug is enabled for db")
+	}
+	m.Printf("Read and execute %v\n", "1/u init")
+	logger.AssertLogged(t, "Read and execute 1/u init", log.NameKey, MigrateLoggerName)
+}
+
+// lockedDriver is a database.Driver whose lock is held elsewhere for the first held attempts.
+type lockedDriver struct {
+	database.Driver
+	held     int
+	attempts int
+}
+
+func (d *lockedDriver) Lock() error {
+	d.attempts++
+	if d.attempts <= d.held {
+		return database.ErrLocked
+	}
+	return nil
+}
+
+func TestMigrationLockTimeout(t *testing.T) {
+	db := &DB{}
+	m, err := db.newMigrate("file://"+t.TempDir(), &lockedDriver{})
+	if err != nil {
+		t.Fatalf("creating migrator: %v", err)
+	}
+	if want := migrate.DefaultLockTimeout + mysqlLockWait; m.LockTimeout != want {
+		t.Errorf("expected default migrate lock timeout %s, got %s", want, m.LockTimeout)
+	}
+
+	db.SetMigrationLockTimeout(time.Second)
+	m, err = db.newMigrate("file://"+t.TempDir(), &lockedDriver{})
+	if err != nil {
+		t.Fatalf("creating migrator: %v", err)
+	}
+	if want := time.Second + mysqlLockWait; m.LockTimeout != want {
+		t.Errorf("expected migrate lock timeout %s, got %s", want, m.LockTimeout)
+	}
+
+	drv := &lockedDriver{held: 2}
+	w := &lockWaiter{Driver: drv, timeout: time.Second}
+	if err := w.Lock(); err != nil {
+		t.Errorf("expected the lock once released, got %v", err)
+	}
+	if drv.attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", drv.attempts)
+	}
+
+	w = &lockWaiter{Driver: &lockedDriver{held: 1000}, timeout: 3 * lockRetryDelay}
+	if err := w.Lock(); !errors.Is(err, migrate.ErrLockTimeout) {
+		t.Errorf("expected migrate.ErrLockTimeout, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Default values for the resilience layer.  They suit a pool that is expected to recover from a
+// database restart or failover within a few seconds.
+const (
+	DefaultMaxAttempts      int           = 3
+	DefaultRetryBaseDelay   time.Duration = 50 * time.Millisecond
+	DefaultRetryMaxDelay    time.Duration = 1 * time.Second
+	DefaultBreakerThreshold int           = 5
+	DefaultBreakerCooldown  time.Duration = 10 * time.Second
+)
+
+// ErrCircuitOpen is matched by errors.Is for every *CircuitOpenError returned by the DB.
+var ErrCircuitOpen = errors.New("sql: circuit breaker is open")
+
+// CircuitOpenError is returned instead of calling the database whilst the circuit breaker is open.
+type CircuitOpenError struct {
+	RetryAfter time.Duration // time remaining until the breaker lets a probe request through.
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrCircuitOpen, e.RetryAfter)
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// ResiliencePolicy configures retries of transient connection errors and the circuit breaker.
+// Zero values are replaced with the package defaults.
+type ResiliencePolicy struct {
+	MaxAttempts      int           // total attempts per call, including the first.
+	RetryBaseDelay   time.Duration // backoff before the second attempt, doubled for each attempt after.
+	RetryMaxDelay    time.Duration // upper bound of the backoff.
+	BreakerThreshold int           // consecutive transient failures before the breaker opens.
+	BreakerCooldown  time.Duration // time the breaker stays open before allowing a probe.
+}
+
+// DefaultResiliencePolicy returns a policy populated with the package defaults.
+func DefaultResiliencePolicy() ResiliencePolicy {
+	return ResiliencePolicy{
+		MaxAttempts:      DefaultMaxAttempts,
+		RetryBaseDelay:   DefaultRetryBaseDelay,
+		RetryMaxDelay:    DefaultRetryMaxDelay,
+		BreakerThreshold: DefaultBreakerThreshold,
+		BreakerCooldown:  DefaultBreakerCooldown,
+	}
+}
+
+// SetResilience opts the DB in to retrying transient connection errors and failing fast once the
+// database is considered down.  It should be called before the DB is shared between goroutines.
+// ExecContext and QueryContext are only retried when the error shows the statement never reached the
+// database, such as a failure to connect, as a statement lost with its connection may still have been
+// applied, and queries may write too, e.g. INSERT ... RETURNING or SELECT ... FOR UPDATE.
+// QueryRowContext is not covered, as a *sql.Row cannot carry the breaker's error; use QueryContext
+// where fail fast behaviour is required.
+func (db *DB) SetResilience(p ResiliencePolicy) {
+	def := DefaultResiliencePolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.RetryBaseDelay <= 0 {
+		p.RetryBaseDelay = def.RetryBaseDelay
+	}
+	if p.RetryMaxDelay <= 0 {
+		p.RetryMaxDelay = def.RetryMaxDelay
+	}
+	if p.BreakerThreshold <= 0 {
+		p.BreakerThreshold = def.BreakerThreshold
+	}
+	if p.BreakerCooldown <= 0 {
+		p.BreakerCooldown = def.BreakerCooldown
+	}
+	db.breaker = &breaker{policy: p, log: db.Log}
+}
+
+// do runs fn under the DB's resilience policy, if one has been set, retrying the errors for which
+// retry reports true.
+func (db *DB) do(ctx context.Context, retry func(error) bool, fn func() error) error {
+	if db.breaker == nil {
+		return fn()
+	}
+	return db.breaker.do(ctx, retry, fn)
+}
+
+// breakerState is the state of a circuit breaker.
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is a consecutive failure circuit breaker combined with a retry loop.
+type breaker struct {
+	policy   ResiliencePolicy
+	log      func(msg string, fields ...interface{}) error
+	mu       sync.Mutex
+	state    breakerState
+	failures int       // consecutive transient failures whilst closed.
+	openedAt time.Time // time the breaker last opened.
+	probing  bool      // a half-open probe is in flight.
+}
+
+// do calls fn until it succeeds, returns an error for which retry reports false, or runs out of attempts.
+func (b *breaker) do(ctx context.Context, retry func(error) bool, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < b.policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, b.backoff(attempt)); err != nil {
+				return err
+			}
+		}
+		probe, allowErr := b.allow()
+		if allowErr != nil {
+			return allowErr
+		}
+		err = fn()
+		b.record(probe, err)
+		if !isTransient(err) || !retry(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// backoff returns a delay with full jitter for the given retry attempt.
+func (b *breaker) backoff(attempt int) time.Duration {
+	d := b.policy.RetryBaseDelay << (attempt - 1)
+	if d <= 0 || d > b.policy.RetryMaxDelay {
+		d = b.policy.RetryMaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// allow reports whether a call may go to the database, and whether the call is the probe that
+// decides if a half-open breaker closes.
+func (b *breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case stateOpen:
+		remaining := b.policy.BreakerCooldown - time.Since(b.openedAt)
+		if remaining > 0 {
+			b.mu.Unlock()
+			return false, &CircuitOpenError{RetryAfter: remaining}
+		}
+		b.state = stateHalfOpen
+		b.probing = true
+		probe = true
+	case stateHalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return false, &CircuitOpenError{}
+		}
+		b.probing = true
+		probe = true
+	}
+	to, failures := b.state, b.failures
+	b.mu.Unlock()
+	b.logChange(from, to, failures)
+	return probe, nil
+}
+
+// record updates the breaker with the result of a call.  Whilst the breaker is not closed only the
+// probe's result counts, so that calls started before the breaker opened cannot close it.  A call
+// cancelled by its context says nothing about the database and is not counted either way.
+func (b *breaker) record(probe bool, err error) {
+	b.mu.Lock()
+	from := b.state
+	if probe {
+		b.probing = false
+	}
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+	case b.state == stateClosed:
+		if !isTransient(err) {
+			b.failures = 0
+			break
+		}
+		b.failures++
+		if b.failures >= b.policy.BreakerThreshold {
+			b.open()
+		}
+	case probe && isTransient(err):
+		b.failures++
+		b.open()
+	case probe:
+		b.failures = 0
+		b.state = stateClosed
+	}
+	to, failures := b.state, b.failures
+	b.mu.Unlock()
+	b.logChange(from, to, failures)
+}
+
+// open must be called with b.mu held.
+func (b *breaker) open() {
+	b.state = stateOpen
+	b.openedAt = time.Now()
+}
+
+// logChange logs a change of state.  It is called once b.mu is released, so that a slow logger does
+// not hold up other calls.
+func (b *breaker) logChange(from, to breakerState, failures int) {
+	if from == to {
+		return
+	}
+	b.log("circuit breaker state changed", "from", from.String(), "to", to.String(), "failures", failures)
+}
+
+// isTransient reports whether err is a connection level error that is worth retrying.
+// Errors from the query itself, such as ErrNoRows or syntax errors, are not.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isUnsent reports whether err shows that a statement never reached the database, so can be retried
+// even if it is not idempotent.  database/sql only returns driver.ErrBadConn when the driver reports
+// it before the statement was sent, and a failed dial has no connection to send on.
+func isUnsent(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// sleep waits for d or until the ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
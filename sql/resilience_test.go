@@ -0,0 +1,170 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	newBreaker := func() *breaker {
+		return &breaker{
+			policy: ResiliencePolicy{
+				MaxAttempts:      3,
+				RetryBaseDelay:   time.Millisecond,
+				RetryMaxDelay:    time.Millisecond,
+				BreakerThreshold: 2,
+				BreakerCooldown:  time.Hour,
+			},
+			log: func(string, ...interface{}) error { return nil },
+		}
+	}
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		b := newBreaker()
+		b.policy.BreakerThreshold = 10
+		calls := 0
+		err := b.do(context.Background(), isTransient, func() error {
+			calls++
+			if calls < 3 {
+				return driver.ErrBadConn
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("does not retry query errors", func(t *testing.T) {
+		b := newBreaker()
+		calls := 0
+		err := b.do(context.Background(), isTransient, func() error {
+			calls++
+			return ErrNoRows
+		})
+		if !errors.Is(err, ErrNoRows) {
+			t.Errorf("expected ErrNoRows, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("fails fast once open", func(t *testing.T) {
+		b := newBreaker()
+		calls := 0
+		fn := func() error {
+			calls++
+			return driver.ErrBadConn
+		}
+		err := b.do(context.Background(), isTransient, fn)
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected ErrCircuitOpen, got %v", err)
+		}
+		calls = 0
+		err = b.do(context.Background(), isTransient, fn)
+		var openErr *CircuitOpenError
+		if !errors.As(err, &openErr) {
+			t.Fatalf("expected *CircuitOpenError, got %v", err)
+		}
+		if calls != 0 {
+			t.Errorf("expected no calls whilst open, got %d", calls)
+		}
+	})
+
+	t.Run("closes after a successful probe", func(t *testing.T) {
+		b := newBreaker()
+		b.state = stateOpen
+		b.openedAt = time.Now().Add(-2 * time.Hour)
+		if err := b.do(context.Background(), isTransient, func() error { return nil }); err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+		if b.state != stateClosed {
+			t.Errorf("expected state %s, got %s", stateClosed, b.state)
+		}
+	})
+
+	t.Run("only retries unsent statements for exec", func(t *testing.T) {
+		tests := []struct {
+			err   error
+			calls int
+		}{
+			{err: io.ErrUnexpectedEOF, calls: 1},
+			{err: &net.OpError{Op: "read", Err: errors.New("connection reset")}, calls: 1},
+			{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, calls: 3},
+			{err: driver.ErrBadConn, calls: 3},
+		}
+		for _, tt := range tests {
+			b := newBreaker()
+			b.policy.BreakerThreshold = 10
+			calls := 0
+			b.do(context.Background(), isUnsent, func() error {
+				calls++
+				return tt.err
+			})
+			if calls != tt.calls {
+				t.Errorf("%v: expected %d calls, got %d", tt.err, tt.calls, calls)
+			}
+		}
+	})
+
+	t.Run("only the probe closes the breaker", func(t *testing.T) {
+		b := newBreaker()
+		b.state = stateOpen
+		b.openedAt = time.Now().Add(-2 * time.Hour)
+		// a call allowed before the breaker opened succeeds whilst the probe is in flight.
+		b.record(false, nil)
+		if b.state != stateOpen {
+			t.Errorf("expected state %s, got %s", stateOpen, b.state)
+		}
+		probe, err := b.allow()
+		if !probe || err != nil {
+			t.Fatalf("expected a probe, got %t, %v", probe, err)
+		}
+		b.record(false, nil)
+		if b.state != stateHalfOpen {
+			t.Errorf("expected state %s, got %s", stateHalfOpen, b.state)
+		}
+		b.record(true, nil)
+		if b.state != stateClosed {
+			t.Errorf("expected state %s, got %s", stateClosed, b.state)
+		}
+	})
+
+	t.Run("cancelled probe does not close the breaker", func(t *testing.T) {
+		b := newBreaker()
+		b.state = stateOpen
+		b.openedAt = time.Now().Add(-2 * time.Hour)
+		err := b.do(context.Background(), isTransient, func() error { return context.Canceled })
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if b.state != stateHalfOpen || b.probing {
+			t.Errorf("expected state %s with no probe in flight, got %s, probing %t", stateHalfOpen, b.state, b.probing)
+		}
+	})
+
+	t.Run("logs without holding the lock", func(t *testing.T) {
+		b := newBreaker()
+		logged := 0
+		b.log = func(string, ...interface{}) error {
+			// would deadlock if the breaker's lock were held.
+			b.mu.Lock()
+			b.mu.Unlock()
+			logged++
+			return nil
+		}
+		b.do(context.Background(), isTransient, func() error { return driver.ErrBadConn })
+		if logged != 1 {
+			t.Errorf("expected 1 state change logged, got %d", logged)
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/goaferlx/go-core/log"
 )
 
 // Default values for configuring the DB connection pool.  Values taken from
@@ -20,24 +21,61 @@ const (
 
 type DB struct {
 	*sql.DB
-	path string // path to migration files.
+	log.Logger
+	cfg         Config        // config the DB was opened with.
+	path        string        // path to migration files.
+	lockTimeout time.Duration // max time to wait for the migration lock.
+	guard       *ResetGuard   // nil unless SetResetGuard has been called.
+	breaker     *breaker      // nil unless SetResilience has been called.
+	stmts       *stmtCache    // nil unless SetStmtCacheSize has been called.
+	auditTable  string        // table change rows are written to, DefaultAuditTable if empty.
+}
+
+// Log implements the log.Logger interface.  Logging will be passed to the DBs logger if one is declared, otherwise handled
+// by the log package singleton.
+func (db *DB) Log(msg string, fields ...interface{}) error {
+	return db.logger().Log(msg, fields...)
+}
+
+// LogLevel implements the log.LevelLogger interface, passing the level through to the DBs logger.
+func (db *DB) LogLevel(level log.Level, msg string, fields ...interface{}) error {
+	return log.LogLevel(db.logger(), level, msg, fields...)
+}
+
+// Enabled implements the log.LevelLogger interface.
+func (db *DB) Enabled(level log.Level) bool {
+	return log.Enabled(db.logger(), level)
+}
+
+// logger returns the DBs logger if one is declared, otherwise the log package singleton.
+func (db *DB) logger() log.Logger {
+	if db.Logger == nil {
+		return log.DefaultLogger
+	}
+	return db.Logger
 }
 
 // BeginTx wraps the sql.BeginTx and sets a tx time.
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
-	tx, err := db.DB.BeginTx(ctx, nil)
+	var tx *sql.Tx
+	err := db.do(ctx, isTransient, func() (err error) {
+		tx, err = db.DB.BeginTx(ctx, nil)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &Tx{
 		now: time.Now().UTC(),
 		Tx:  tx,
+		db:  db,
 	}, nil
 }
 
 type Tx struct {
 	now time.Time
 	*sql.Tx
+	db *DB // DB the Tx was started on.
 }
 
 // Now returns the time that the Tx started at.
@@ -45,8 +83,138 @@ func (tx *Tx) Now() time.Time {
 	return tx.now.UTC()
 }
 
+// ExecContext wraps sql.Tx.ExecContext, rebinding a cached statement to the Tx if the DB has a statement cache.
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx.db == nil || tx.db.stmts == nil {
+		return tx.Tx.ExecContext(ctx, query, args...)
+	}
+	var res sql.Result
+	err := tx.db.stmts.with(ctx, tx.db.DB, tx.Tx, query, func(s *sql.Stmt) (err error) {
+		res, err = s.ExecContext(ctx, args...)
+		return err
+	})
+	return res, err
+}
+
+// QueryContext wraps sql.Tx.QueryContext, rebinding a cached statement to the Tx if the DB has a statement cache.
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx.db == nil || tx.db.stmts == nil {
+		return tx.Tx.QueryContext(ctx, query, args...)
+	}
+	var rows *sql.Rows
+	err := tx.db.stmts.with(ctx, tx.db.DB, tx.Tx, query, func(s *sql.Stmt) (err error) {
+		rows, err = s.QueryContext(ctx, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext wraps sql.Tx.QueryRowContext, rebinding a cached statement to the Tx if the DB has a statement cache.
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if tx.db == nil || tx.db.stmts == nil {
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	}
+	var row *sql.Row
+	tx.db.stmts.with(ctx, tx.db.DB, tx.Tx, query, func(s *sql.Stmt) error {
+		row = s.QueryRowContext(ctx, args...)
+		return row.Err()
+	})
+	if row == nil {
+		// the statement could not be prepared, let the Tx report the error.
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
 var ErrNoRows = sql.ErrNoRows
 
+// ExecContext wraps sql.DB.ExecContext, applying the resilience policy if one is set.  The statement
+// is only retried if it never reached the database.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := db.do(ctx, isUnsent, func() (err error) {
+		if db.stmts == nil {
+			res, err = db.DB.ExecContext(ctx, query, args...)
+			return err
+		}
+		return db.stmts.with(ctx, db.DB, nil, query, func(s *sql.Stmt) error {
+			res, err = s.ExecContext(ctx, args...)
+			return err
+		})
+	})
+	return res, err
+}
+
+// Exec calls ExecContext with a background context.
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext wraps sql.DB.QueryContext, applying the resilience policy if one is set.  As for
+// ExecContext, the query is only retried if it never reached the database.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := db.do(ctx, isUnsent, func() (err error) {
+		if db.stmts == nil {
+			rows, err = db.DB.QueryContext(ctx, query, args...)
+			return err
+		}
+		return db.stmts.with(ctx, db.DB, nil, query, func(s *sql.Stmt) error {
+			rows, err = s.QueryContext(ctx, args...)
+			return err
+		})
+	})
+	return rows, err
+}
+
+// QueryRowContext wraps sql.DB.QueryRowContext, using a cached statement if the DB has a statement cache.
+// It is not covered by the resilience policy.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if db.stmts == nil {
+		return db.DB.QueryRowContext(ctx, query, args...)
+	}
+	var row *sql.Row
+	db.stmts.with(ctx, db.DB, nil, query, func(s *sql.Stmt) error {
+		row = s.QueryRowContext(ctx, args...)
+		return row.Err()
+	})
+	if row == nil {
+		// the statement could not be prepared, let the DB report the error.
+		return db.DB.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// QueryRow calls QueryRowContext with a background context.
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// Close closes any cached statements before closing the DB.
+func (db *DB) Close() error {
+	if db.stmts != nil {
+		db.stmts.close()
+	}
+	return db.DB.Close()
+}
+
+// Query calls QueryContext with a background context.
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// PingContext wraps sql.DB.PingContext, applying the resilience policy if one is set.
+func (db *DB) PingContext(ctx context.Context) error {
+	return db.do(ctx, isTransient, func() error {
+		return db.DB.PingContext(ctx)
+	})
+}
+
+// Ping calls PingContext with a background context.
+func (db *DB) Ping() error {
+	return db.PingContext(context.Background())
+}
+
 // Open is a convenience function that wraps sql.Open to establish a connection to the DB
 // and verifies the connection, in one step, as well as setting sensible default values
 // for the connection pool.
@@ -65,5 +233,5 @@ func Open(cfg Config) (*DB, error) {
 	db.SetConnMaxLifetime(DefaultMaxLifetime)
 	db.SetConnMaxIdleTime(DefaultMaxIdleTime)
 
-	return &DB{DB: db}, nil
+	return &DB{DB: db, cfg: cfg}, nil
 }
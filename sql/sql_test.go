@@ -0,0 +1,23 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/goaferlx/go-core/log"
+	"github.com/goaferlx/go-core/log/logtest"
+)
+
+func TestDBLogLevel(t *testing.T) {
+	logger := logtest.New(log.MinLevel(log.LevelWarn))
+	db := &DB{Logger: logger}
+
+	if db.Enabled(log.LevelInfo) || !db.Enabled(log.LevelError) {
+		t.Errorf("expected the DB to report the levels enabled on its logger")
+	}
+	log.Error(db, "connection lost")
+	db.Log("connected")
+	if got := logger.Entries(logtest.AtLevel(log.LevelError)); len(got) != 1 {
+		t.Errorf("expected 1 entry logged at error, got %v", got)
+	}
+	logger.AssertNotLogged(t, "connected")
+}
@@ -0,0 +1,191 @@
+package sql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+// StmtCacheStats reports the usage of a DBs prepared statement cache.
+type StmtCacheStats struct {
+	Hits      uint64 // queries served by a cached statement.
+	Misses    uint64 // queries that required a statement to be prepared.
+	Evictions uint64 // statements closed to make room, or because their connection failed.
+	Len       int    // statements currently cached.
+}
+
+// SetStmtCacheSize opts the DB in to caching up to size prepared statements, keyed by query text,
+// and reused by ExecContext, QueryContext and QueryRowContext on the DB and its transactions.
+// Transactions only use statements already cached by the DB, preparing others without caching them.
+// The least recently used statement is closed when the cache is full.  A size of zero or less
+// disables the cache and closes any cached statements.  It should be called before the DB is
+// shared between goroutines.
+func (db *DB) SetStmtCacheSize(size int) {
+	if db.stmts != nil {
+		db.stmts.close()
+		db.stmts = nil
+	}
+	if size > 0 {
+		db.stmts = newStmtCache(size)
+	}
+}
+
+// StmtCacheStats returns the current statistics of the prepared statement cache.
+func (db *DB) StmtCacheStats() StmtCacheStats {
+	if db.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return db.stmts.stats()
+}
+
+// cachedStmt is a prepared statement held in the cache.  It is only closed once it has been
+// evicted and no caller is using it.
+type cachedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache is an LRU cache of prepared statements.
+type stmtCache struct {
+	mu     sync.Mutex
+	size   int
+	ll     *list.List // most recently used at the front.
+	items  map[string]*list.Element
+	counts StmtCacheStats
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// lookup returns the cached statement for query, or nil if it is not cached.  A statement that
+// is returned must be followed by a call to release.
+func (c *stmtCache) lookup(query string) *cachedStmt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[query]
+	if !ok {
+		c.counts.Misses++
+		return nil
+	}
+	c.ll.MoveToFront(el)
+	cs := el.Value.(*cachedStmt)
+	cs.refs++
+	c.counts.Hits++
+	return cs
+}
+
+// acquire returns the cached statement for query, preparing it on db if required.
+// Every call must be followed by a call to release.
+func (c *stmtCache) acquire(ctx context.Context, db *sql.DB, query string) (*cachedStmt, error) {
+	if cs := c.lookup(query); cs != nil {
+		return cs, nil
+	}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// another caller may have prepared the same query in the meantime.
+	if el, ok := c.items[query]; ok {
+		stmt.Close()
+		c.ll.MoveToFront(el)
+		cs := el.Value.(*cachedStmt)
+		cs.refs++
+		return cs, nil
+	}
+	cs := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.ll.PushFront(cs)
+	for c.ll.Len() > c.size {
+		c.evict(c.ll.Back())
+	}
+	return cs, nil
+}
+
+// release gives up a reference to cs.  If err shows the statements connection has gone bad, it is evicted
+// so that the next caller prepares it afresh.
+func (c *stmtCache) release(cs *cachedStmt, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cs.refs--
+	if errors.Is(err, driver.ErrBadConn) && !cs.evicted {
+		c.evict(c.items[cs.query])
+	}
+	if cs.evicted && cs.refs == 0 {
+		cs.stmt.Close()
+	}
+}
+
+// evict must be called with c.mu held.
+func (c *stmtCache) evict(el *list.Element) {
+	cs := c.ll.Remove(el).(*cachedStmt)
+	delete(c.items, cs.query)
+	cs.evicted = true
+	c.counts.Evictions++
+	if cs.refs == 0 {
+		cs.stmt.Close()
+	}
+}
+
+// with calls fn with the cached statement for query, bound to tx if it is not nil.
+func (c *stmtCache) with(ctx context.Context, db *sql.DB, tx *sql.Tx, query string, fn func(*sql.Stmt) error) error {
+	if tx != nil {
+		return c.withTx(ctx, tx, query, fn)
+	}
+	cs, err := c.acquire(ctx, db, query)
+	if err != nil {
+		return err
+	}
+	err = fn(cs.stmt)
+	c.release(cs, err)
+	return err
+}
+
+// withTx calls fn with the cached statement for query bound to tx.  A query that is not cached is
+// prepared on tx and closed afterwards, as preparing it on the DB needs a second connection,
+// which never becomes free whilst tx holds the only one of a pool limited by SetMaxOpenConns(1).
+func (c *stmtCache) withTx(ctx context.Context, tx *sql.Tx, query string, fn func(*sql.Stmt) error) error {
+	cs := c.lookup(query)
+	if cs == nil {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		return fn(stmt)
+	}
+	// closing a transaction specific statement leaves the cached statement open.
+	stmt := tx.StmtContext(ctx, cs.stmt)
+	defer stmt.Close()
+	err := fn(stmt)
+	c.release(cs, err)
+	return err
+}
+
+// close evicts every statement.
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.ll.Len() > 0 {
+		c.evict(c.ll.Back())
+	}
+}
+
+func (c *stmtCache) stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.counts
+	s.Len = c.ll.Len()
+	return s
+}
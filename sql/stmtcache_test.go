@@ -0,0 +1,141 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubDriver is a database/sql driver that accepts every statement and returns no rows.
+type stubDriver struct {
+	prepared  atomic.Int64
+	closed    atomic.Int64
+	conns     atomic.Int64
+	returnErr error
+
+	mu     sync.Mutex
+	events []string // statements executed and transactions begun and ended, prefixed with their connection.
+}
+
+func (d *stubDriver) Open(string) (driver.Conn, error) { return stubConn{d, d.conns.Add(1)}, nil }
+
+func (d *stubDriver) record(conn int64, event string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, fmt.Sprintf("%d: %s", conn, event))
+}
+
+type stubConn struct {
+	d  *stubDriver
+	id int64
+}
+
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.prepared.Add(1)
+	return stubStmt{c, query}, nil
+}
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { c.d.record(c.id, "BEGIN"); return c, nil }
+func (c stubConn) Commit() error             { c.d.record(c.id, "COMMIT"); return nil }
+func (c stubConn) Rollback() error           { c.d.record(c.id, "ROLLBACK"); return nil }
+
+type stubStmt struct {
+	c     stubConn
+	query string
+}
+
+func (s stubStmt) Close() error  { s.c.d.closed.Add(1); return nil }
+func (s stubStmt) NumInput() int { return -1 }
+func (s stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.c.d.record(s.c.id, s.query)
+	return driver.RowsAffected(1), s.c.d.returnErr
+}
+func (s stubStmt) Query([]driver.Value) (driver.Rows, error) { return nil, driver.ErrSkip }
+
+func TestStmtCache(t *testing.T) {
+	stub := &stubDriver{}
+	sql.Register(t.Name(), stub)
+	sqlDB, err := sql.Open(t.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &DB{DB: sqlDB}
+	db.SetStmtCacheSize(2)
+	ctx := context.Background()
+
+	for _, q := range []string{"a", "b", "a", "c", "a"} {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := StmtCacheStats{Hits: 2, Misses: 3, Evictions: 1, Len: 2}
+	if got := db.StmtCacheStats(); got != want {
+		t.Errorf("expected stats %+v, got %+v", want, got)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.StmtCacheStats().Hits; got != 3 {
+		t.Errorf("expected tx to hit the cache, got %d hits", got)
+	}
+
+	stub.returnErr = driver.ErrBadConn
+	db.ExecContext(ctx, "a")
+	stub.returnErr = nil
+	if got := db.StmtCacheStats().Len; got != 1 {
+		t.Errorf("expected statement on a bad connection to be evicted, got %d cached", got)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.StmtCacheStats().Len; got != 0 {
+		t.Errorf("expected empty cache after Close, got %d cached", got)
+	}
+}
+
+func TestStmtCacheSingleConn(t *testing.T) {
+	stub := &stubDriver{}
+	sql.Register(t.Name(), stub)
+	sqlDB, err := sql.Open(t.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	db := &DB{DB: sqlDB}
+	db.SetStmtCacheSize(2)
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, q := range []string{"a", "b", "b"} {
+		if _, err := tx.ExecContext(ctx, q); err != nil {
+			t.Fatalf("expected %q to run on the transactions connection, got %v", q, err)
+		}
+	}
+	want := StmtCacheStats{Hits: 1, Misses: 3, Len: 1}
+	if got := db.StmtCacheStats(); got != want {
+		t.Errorf("expected stats %+v, got %+v", want, got)
+	}
+}